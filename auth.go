@@ -0,0 +1,255 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// defaultTokenEnv is the environment variable read for a bearer token.
+const defaultTokenEnv = "SPRING_INITIALIZR_TOKEN"
+
+// authConfig describes how requests to the Initializr server authenticate.
+// A bearer token wins over .netrc; extra headers are always added.
+type authConfig struct {
+	tokenEnv  string // environment variable holding a bearer token
+	tokenFile string // file holding a bearer token
+	netrcFile string // "" means $NETRC or ~/.netrc
+	headers   headerList
+}
+
+// headerList is a repeatable "Name: value" flag.
+type headerList []string
+
+func (h *headerList) String() string { return strings.Join(*h, ", ") }
+
+func (h *headerList) Set(s string) error {
+	if _, _, err := splitHeader(s); err != nil {
+		return err
+	}
+	*h = append(*h, s)
+	return nil
+}
+
+func splitHeader(s string) (string, string, error) {
+	name, value, ok := strings.Cut(s, ":")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" || strings.ContainsAny(name, " \t") {
+		return "", "", fmt.Errorf("invalid header %q (want \"Name: value\")", s)
+	}
+	return textproto.CanonicalMIMEHeaderKey(name), strings.TrimSpace(value), nil
+}
+
+// registerAuthFlags adds the authentication flags to fs.
+func registerAuthFlags(fs *flag.FlagSet, c *authConfig) {
+	fs.StringVar(&c.tokenEnv, "token-env", defaultTokenEnv, "Environment variable holding a bearer token")
+	fs.StringVar(&c.tokenFile, "token-file", "", "File holding a bearer token")
+	fs.StringVar(&c.netrcFile, "netrc-file", "", "netrc file for basic auth (default: $NETRC or ~/.netrc)")
+	fs.Var(&c.headers, "header", "Extra request header \"Name: value\" (repeatable)")
+}
+
+// credentials are the resolved authentication settings for one host.
+type credentials struct {
+	host        string // requests to other hosts are sent unchanged
+	bearer      string
+	bearerFrom  string // description of the token source, for logs
+	user, pass  string
+	headers     http.Header
+	headerNames []string
+}
+
+// resolve reads token files, the environment and .netrc for baseURL's host.
+// It returns nil when there is nothing to add.
+func (c authConfig) resolve(baseURL string) (*credentials, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	cr := &credentials{host: u.Host, headers: http.Header{}}
+	for _, h := range c.headers {
+		name, value, err := splitHeader(h)
+		if err != nil {
+			return nil, err
+		}
+		cr.headers.Set(name, value)
+	}
+	for name := range cr.headers {
+		cr.headerNames = append(cr.headerNames, name)
+	}
+	sort.Strings(cr.headerNames)
+
+	switch {
+	case c.tokenFile != "":
+		b, err := os.ReadFile(c.tokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("read token file: %w", err)
+		}
+		cr.bearer, cr.bearerFrom = strings.TrimSpace(string(b)), c.tokenFile
+		if cr.bearer == "" {
+			return nil, fmt.Errorf("token file %s is empty", c.tokenFile)
+		}
+	case c.tokenEnv != "" && strings.TrimSpace(os.Getenv(c.tokenEnv)) != "":
+		cr.bearer, cr.bearerFrom = strings.TrimSpace(os.Getenv(c.tokenEnv)), "$"+c.tokenEnv
+	default:
+		path, explicit := c.netrcFile, c.netrcFile != ""
+		if !explicit {
+			path = defaultNetrcPath()
+		}
+		user, pass, err := netrcLookup(path, u.Hostname())
+		if err != nil && (explicit || !errors.Is(err, fs.ErrNotExist)) {
+			return nil, fmt.Errorf("netrc: %w", err)
+		}
+		cr.user, cr.pass = user, pass
+	}
+	if cr.bearer == "" && cr.user == "" && len(cr.headers) == 0 {
+		return nil, nil
+	}
+	return cr, nil
+}
+
+// describe summarizes the credentials without revealing any secret.
+func (cr *credentials) describe() string {
+	var parts []string
+	switch {
+	case cr.bearer != "":
+		parts = append(parts, "bearer token from "+cr.bearerFrom)
+	case cr.user != "":
+		parts = append(parts, "basic auth from netrc (login "+cr.user+")")
+	}
+	if len(cr.headerNames) > 0 {
+		parts = append(parts, "extra headers "+strings.Join(cr.headerNames, ", "))
+	}
+	return strings.Join(parts, "; ") + " for " + cr.host
+}
+
+// authTransport adds credentials to requests for one host.
+type authTransport struct {
+	creds *credentials
+	next  http.RoundTripper
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Any scheme and port of the host match, as links the server
+	// advertises may use its own.
+	host := (&url.URL{Host: t.creds.host}).Hostname()
+	if !strings.EqualFold(req.URL.Hostname(), host) {
+		return t.next.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	for name, values := range t.creds.headers {
+		req.Header[name] = values
+	}
+	if req.Header.Get("Authorization") == "" {
+		switch {
+		case t.creds.bearer != "":
+			req.Header.Set("Authorization", "Bearer "+t.creds.bearer)
+		case t.creds.user != "":
+			req.SetBasicAuth(t.creds.user, t.creds.pass)
+		}
+	}
+	return t.next.RoundTrip(req)
+}
+
+func defaultNetrcPath() string {
+	if p := os.Getenv("NETRC"); p != "" {
+		return p
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".netrc")
+}
+
+// netrcLookup returns the login and password for host from a netrc file,
+// falling back to its "default" entry.
+func netrcLookup(path, host string) (user, pass string, err error) {
+	if path == "" {
+		return "", "", fs.ErrNotExist
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "", "", err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	sc.Split(bufio.ScanWords)
+	var (
+		inMatch, inDefault, found bool
+		defUser, defPass          string
+	)
+	for sc.Scan() {
+		switch tok := sc.Text(); tok {
+		case "machine":
+			if found {
+				return user, pass, nil
+			}
+			inDefault = false
+			inMatch = sc.Scan() && sc.Text() == host
+			found = inMatch
+		case "default":
+			if found {
+				return user, pass, nil
+			}
+			inMatch, inDefault = false, true
+		case "login", "password", "account":
+			if !sc.Scan() {
+				break
+			}
+			v := sc.Text()
+			switch {
+			case inMatch && tok == "login":
+				user = v
+			case inMatch && tok == "password":
+				pass = v
+			case inDefault && tok == "login":
+				defUser = v
+			case inDefault && tok == "password":
+				defPass = v
+			}
+		case "macdef":
+			// Macro bodies run to the next blank line; word scanning cannot
+			// see that, so stop here rather than misread the rest.
+			if found {
+				return user, pass, nil
+			}
+			return defUser, defPass, sc.Err()
+		}
+	}
+	if found {
+		return user, pass, sc.Err()
+	}
+	return defUser, defPass, sc.Err()
+}
+
+// redactURL hides user info (which may hold a token or password) in a URL
+// meant for display.
+func redactURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil || u.User == nil {
+		return s
+	}
+	u.User = url.User("REDACTED")
+	return u.String()
+}
+
+// stripUserinfo removes user info from a URL that is stored for reuse,
+// where a placeholder like redactURL's would be sent as a credential.
+func stripUserinfo(s string) string {
+	u, err := url.Parse(s)
+	if err != nil || u.User == nil {
+		return s
+	}
+	u.User = nil
+	return u.String()
+}
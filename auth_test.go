@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mikoto2000/spring-initializr-cli/initializrtest"
+)
+
+func TestAuthBearerOnlyForBaseHost(t *testing.T) {
+	var seen []string
+	record := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = append(seen, r.Header.Get("Authorization")+"|"+r.Header.Get("X-Team"))
+	})
+	base := httptest.NewServer(record)
+	defer base.Close()
+	other := httptest.NewServer(record)
+	defer other.Close()
+
+	t.Setenv("TEST_INITIALIZR_TOKEN", " s3cret\n")
+	creds, err := authConfig{tokenEnv: "TEST_INITIALIZR_TOKEN", headers: headerList{"x-team: platform"}}.resolve(base.URL)
+	if err != nil || creds == nil {
+		t.Fatalf("resolve: %v, %v", creds, err)
+	}
+	rt := &authTransport{creds: creds, next: http.DefaultTransport}
+	if err := get(rt, base.URL+"/metadata/client"); err != nil {
+		t.Fatal(err)
+	}
+	// The same host on another port is still the server; another host is not.
+	if err := get(rt, other.URL); err != nil {
+		t.Fatal(err)
+	}
+	if err := get(rt, strings.Replace(other.URL, "127.0.0.1", "localhost", 1)); err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != 3 || seen[0] != "Bearer s3cret|platform" || seen[1] != seen[0] || seen[2] != "|" {
+		t.Fatalf("headers seen = %q", seen)
+	}
+	if d := creds.describe(); strings.Contains(d, "s3cret") || !strings.Contains(d, "$TEST_INITIALIZR_TOKEN") {
+		t.Fatalf("describe() = %q", d)
+	}
+}
+
+func TestAuthTokenFileAndNetrc(t *testing.T) {
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "token")
+	os.WriteFile(tokenFile, []byte("from-file\n"), 0o600)
+	netrc := filepath.Join(dir, "netrc")
+	os.WriteFile(netrc, []byte(`
+machine other.example login nobody password nothing
+machine initializr.example
+  login alice
+  password pa55
+default login anon password guest
+`), 0o600)
+	t.Setenv("TEST_INITIALIZR_TOKEN", "from-env")
+
+	creds, err := authConfig{tokenEnv: "TEST_INITIALIZR_TOKEN", tokenFile: tokenFile, netrcFile: netrc}.resolve("https://initializr.example")
+	if err != nil || creds.bearer != "from-file" {
+		t.Fatalf("token file should win: %+v, %v", creds, err)
+	}
+
+	creds, err = authConfig{netrcFile: netrc}.resolve("https://initializr.example:8443")
+	if err != nil || creds.user != "alice" || creds.pass != "pa55" {
+		t.Fatalf("netrc machine: %+v, %v", creds, err)
+	}
+	if d := creds.describe(); strings.Contains(d, "pa55") {
+		t.Fatalf("describe() leaks the password: %q", d)
+	}
+	creds, err = authConfig{netrcFile: netrc}.resolve("https://unknown.example")
+	if err != nil || creds.user != "anon" || creds.pass != "guest" {
+		t.Fatalf("netrc default: %+v, %v", creds, err)
+	}
+
+	if _, err := (authConfig{netrcFile: filepath.Join(dir, "missing")}).resolve("https://initializr.example"); err == nil {
+		t.Fatal("explicit missing netrc should fail")
+	}
+	t.Setenv("NETRC", filepath.Join(dir, "missing"))
+	if creds, err := (authConfig{}).resolve("https://initializr.example"); creds != nil || err != nil {
+		t.Fatalf("no credentials: %+v, %v", creds, err)
+	}
+}
+
+func TestHeaderFlag(t *testing.T) {
+	var h headerList
+	for _, bad := range []string{"no-colon", ": value", "Bad Name: v"} {
+		if err := h.Set(bad); err == nil {
+			t.Errorf("%q: expected an error", bad)
+		}
+	}
+	if err := h.Set("X-Api-Key: abc"); err != nil || len(h) != 1 {
+		t.Fatalf("Set: %v, %q", err, h)
+	}
+}
+
+func TestApplyConfigHeadersFlagWins(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	os.WriteFile(path, []byte(`{"tokenFile": "token", "headers": {"X-Team": "config", "X-Env": "dev"}}`), 0o644)
+
+	var o options
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	configPath := fs.String("config", "", "")
+	registerAuthFlags(fs, &o.auth)
+	if err := fs.Parse([]string{"--config", path, "--header", "X-Team: flag"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyConfig(fs, *configPath, &o); err != nil {
+		t.Fatal(err)
+	}
+	if o.auth.tokenFile != filepath.Join(filepath.Dir(path), "token") {
+		t.Fatalf("tokenFile = %q", o.auth.tokenFile)
+	}
+	o.auth.tokenFile = ""
+	creds, err := o.auth.resolve("https://initializr.example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.headers.Get("X-Team") != "flag" || creds.headers.Get("X-Env") != "dev" {
+		t.Fatalf("headers = %v", creds.headers)
+	}
+}
+
+func TestDryRunRedactsCredentials(t *testing.T) {
+	srv := initializrtest.NewServer()
+	defer srv.Close()
+	o := options{baseURL: strings.Replace(srv.URL, "http://", "http://user:hunter2@", 1), target: "zip", dryRun: true, timeout: 5}
+	o.auth.tokenEnv = "TEST_INITIALIZR_TOKEN"
+	t.Setenv("TEST_INITIALIZR_TOKEN", "s3cret")
+	t.Setenv("NETRC", filepath.Join(t.TempDir(), "missing"))
+
+	out := captureStdout(t, func() {
+		if err := run(t.Context(), o); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if strings.Contains(out, "hunter2") || strings.Contains(out, "user:") || strings.Contains(out, "s3cret") {
+		t.Fatalf("dry-run output leaks credentials: %q", out)
+	}
+	if !strings.Contains(out, "REDACTED@"+strings.TrimPrefix(srv.URL, "http://")+"/starter.zip") {
+		t.Fatalf("dry-run output = %q", out)
+	}
+}
+
+func captureStdout(t *testing.T, f func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+	f()
+	w.Close()
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+	return buf.String()
+}
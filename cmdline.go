@@ -0,0 +1,121 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/mikoto2000/spring-initializr-cli/initializr"
+)
+
+// commandName is how generated command lines invoke the CLI.
+const commandName = "spring-initializr-cli"
+
+// commandLineSkip lists flags left out of generated command lines: modes and
+// aliases that do not describe the project, and --header, whose values may
+// be secrets.
+var commandLineSkip = map[string]bool{
+	"interactive": true, "i": true, "version": true, "V": true, "license": true, "L": true,
+	"dry-run": true, "share-url": true, "from-url": true, "from-descriptor": true, "config": true,
+	"preset": true, "v": true, "debug-http": true, "header": true,
+}
+
+// commandLine renders the invocation that generates o non-interactively,
+// listing only the flags whose values differ from their defaults.
+func commandLine(o options) string {
+	var cur options
+	fs := flag.NewFlagSet(commandName, flag.ContinueOnError)
+	registerFlags(fs, &cur)
+	// The flags read their values through pointers into cur.
+	cur = o
+	cur.baseURL = redactURL(cur.baseURL)
+	cur.transport.proxy = redactURL(cur.transport.proxy)
+	// Leave out what parseFlags derives on its own.
+	if cur.BaseDir == cur.ArtifactID {
+		cur.BaseDir = ""
+	}
+	if cur.output == cur.ArtifactID+".zip" {
+		cur.output = ""
+	}
+	if cur.PackageName == initializr.SanitizePackage(cur.GroupID+"."+cur.ArtifactID) {
+		cur.PackageName = ""
+	}
+
+	args := []string{commandName}
+	fs.VisitAll(func(f *flag.Flag) {
+		v := f.Value.String()
+		if commandLineSkip[f.Name] || v == f.DefValue {
+			return
+		}
+		if r, ok := f.Value.(interface{ repeated() []string }); ok {
+			for _, v := range r.repeated() {
+				args = append(args, "--"+f.Name, shellQuote(v))
+			}
+			return
+		}
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() && v == "true" {
+			args = append(args, "--"+f.Name)
+			return
+		}
+		args = append(args, "--"+f.Name, shellQuote(v))
+	})
+	return strings.Join(args, " ")
+}
+
+var shellSafe = regexp.MustCompile(`^[A-Za-z0-9_./:,=@%+-]+$`)
+
+// shellQuote quotes s for POSIX shells when it contains anything beyond
+// plain word characters.
+func shellQuote(s string) string {
+	if shellSafe.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// splitShellWords splits a command line into words the way a POSIX shell
+// does for plain words, single and double quotes and backslash escapes. It
+// undoes shellQuote.
+func splitShellWords(s string) ([]string, error) {
+	var words []string
+	var cur strings.Builder
+	inWord := false
+	var quote byte // the open quote character, if any
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote == '\'' && c == '\'', quote == '"' && c == '"':
+			quote = 0
+		case quote == '"' && c == '\\' && i+1 < len(s) && strings.IndexByte("\"\\$`", s[i+1]) >= 0:
+			i++
+			cur.WriteByte(s[i])
+		case quote != 0:
+			cur.WriteByte(c)
+		case c == '\'' || c == '"':
+			quote, inWord = c, true
+		case c == '\\' && i+1 < len(s):
+			i++
+			if s[i] != '\n' { // a backslash-newline continues the line
+				cur.WriteByte(s[i])
+				inWord = true
+			}
+		case c == ' ' || c == '\t' || c == '\n':
+			if inWord {
+				words = append(words, cur.String())
+				cur.Reset()
+				inWord = false
+			}
+		default:
+			cur.WriteByte(c)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote in %q", quote, s)
+	}
+	if inWord {
+		words = append(words, cur.String())
+	}
+	return words, nil
+}
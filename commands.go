@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/mikoto2000/spring-initializr-cli/initializrtest"
+)
+
+// subcommands maps a leading command name to its handler. Any other
+// invocation is parsed as the classic flag-based download.
+var subcommands = map[string]func(ctx context.Context, args []string) error{
+	"serve-mock": runServeMock,
+	"proxy":      runProxy,
+	"upgrade":    runUpgrade,
+	"import":     runImport,
+	"diff":       runDiff,
+}
+
+// lookupSubcommand returns the handler named by the first argument, if any.
+func lookupSubcommand(args []string) (func(ctx context.Context, args []string) error, bool) {
+	if len(args) == 0 {
+		return nil, false
+	}
+	cmd, ok := subcommands[args[0]]
+	return cmd, ok
+}
+
+// serve runs h on ln until ctx is cancelled, then shuts down gracefully.
+func serve(ctx context.Context, ln net.Listener, h http.Handler) error {
+	srv := &http.Server{Handler: h}
+	errc := make(chan error, 1)
+	go func() { errc <- srv.Serve(ln) }()
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return srv.Shutdown(shutdownCtx)
+}
+
+// runServeMock serves the built-in mock Initializr until interrupted.
+func runServeMock(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("serve-mock", flag.ExitOnError)
+	listen := fs.String("listen", "127.0.0.1:8080", "Address to listen on")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: spring-initializr-cli serve-mock [--listen addr]\n\n")
+		fmt.Fprintf(os.Stderr, "Serves recorded Initializr metadata and generates deterministic\nproject archives for offline development and tests.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	ln, err := net.Listen("tcp", *listen)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Mock Spring Initializr listening on http://%s\n", ln.Addr())
+	fmt.Fprintf(os.Stderr, "Use --base-url http://%s\n", ln.Addr())
+	return serve(ctx, ln, initializrtest.NewHandler())
+}
+
+// runProxy serves a local caching proxy in front of an Initializr server.
+func runProxy(ctx context.Context, args []string) error {
+	var o options
+	fs := flag.NewFlagSet("proxy", flag.ExitOnError)
+	listen := fs.String("listen", "127.0.0.1:8080", "Address to listen on")
+	fs.StringVar(&o.baseURL, "base-url", defaultBaseURL, "Upstream Spring Initializr base URL")
+	metaTTL := fs.Duration("metadata-ttl", 10*time.Minute, "How long metadata responses stay fresh")
+	archiveTTL := fs.Duration("archive-ttl", 24*time.Hour, "How long generated archives stay fresh")
+	fs.IntVar(&o.timeout, "timeout", 60, "Upstream timeout in seconds")
+	verbose := fs.Bool("v", false, "Log every request with its cache status")
+	fs.BoolVar(&o.debugHTTP, "debug-http", false, "Log upstream HTTP requests and responses (secrets redacted)")
+	configPath := fs.String("config", defaultConfigPath(), "JSON config file (flags override its values)")
+	registerTransportFlags(fs, &o.transport)
+	registerAuthFlags(fs, &o.auth)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: spring-initializr-cli proxy [--listen addr] [--base-url url] [options]\n\n")
+		fmt.Fprintf(os.Stderr, "Forwards Initializr API calls to --base-url and caches metadata and\narchives in memory. Expired entries are served when the upstream is down.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if err := applyConfig(fs, *configPath, &o); err != nil {
+		return err
+	}
+	if err := o.prepareTransport(); err != nil {
+		return err
+	}
+
+	p, err := newInitializrProxy(o.baseURL, time.Duration(o.timeout)*time.Second)
+	if err != nil {
+		return err
+	}
+	p.client.Transport = o.httpTransport
+	p.metaTTL = *metaTTL
+	p.archiveTTL = *archiveTTL
+	p.logf = newProxyLogger(os.Stderr, *verbose)
+
+	ln, err := net.Listen("tcp", *listen)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Caching proxy for %s listening on http://%s\n", p.upstreamOrigin(), ln.Addr())
+	fmt.Fprintf(os.Stderr, "Use --base-url http://%s\n", ln.Addr())
+	return serve(ctx, ln, p)
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/mikoto2000/spring-initializr-cli/initializr"
+)
+
+// sideArgs turns one side of the diff command into the command-line flags
+// that produce it: a command line (starting with a flag or the command
+// name) is split into words, a URL is a share link for --from-url, a
+// directory or a descriptor file goes to --from-descriptor and any other
+// file is a --preset.
+func sideArgs(spec string) ([]string, error) {
+	s := strings.TrimSpace(spec)
+	switch {
+	case s == "", s == commandName, strings.HasPrefix(s, commandName+" "), strings.HasPrefix(s, "-"):
+		words, err := splitShellWords(s)
+		if err != nil {
+			return nil, err
+		}
+		if len(words) > 0 && words[0] == commandName {
+			words = words[1:]
+		}
+		return words, nil
+	case strings.Contains(s, "://"):
+		return []string{"--from-url", s}, nil
+	}
+	fi, err := os.Stat(s)
+	if err != nil {
+		return nil, err
+	}
+	if fi.IsDir() {
+		return []string{"--from-descriptor", s}, nil
+	}
+	b, err := os.ReadFile(s)
+	if err != nil {
+		return nil, err
+	}
+	var probe struct {
+		Request json.RawMessage `json:"request"`
+	}
+	if json.Unmarshal(b, &probe) == nil && probe.Request != nil {
+		return []string{"--from-descriptor", s}, nil
+	}
+	return []string{"--preset", s}, nil
+}
+
+// sideOptions parses one side of the diff command into options, the way
+// parseFlags reads the command line. config is the --config given to diff,
+// or nil to load the default config file, which may be missing.
+func sideOptions(spec string, config *string) (options, error) {
+	var o options
+	args, err := sideArgs(spec)
+	if err != nil {
+		return o, err
+	}
+	if config != nil {
+		args = append([]string{"--config", *config}, args...)
+	}
+	fs := flag.NewFlagSet(commandName, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	extra := registerFlags(fs, &o)
+	if err := fs.Parse(args); err != nil {
+		return o, err
+	}
+	if fs.NArg() > 0 {
+		return o, fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+	if err := extra.apply(fs, &o); err != nil {
+		return o, err
+	}
+	o.target = "zip"
+	o.fillDefaults()
+	return o, nil
+}
+
+// fieldChange is a project setting that differs between two option sets.
+type fieldChange struct {
+	name     string // the flag name
+	from, to string
+}
+
+// compareOptions lists the settings that differ between a and b and the
+// dependencies b adds and removes. Values derived from the artifact ID on
+// both sides are not reported on their own.
+func compareOptions(a, b options) (fields []fieldChange, added, removed []string) {
+	show := func(v string) string {
+		if strings.TrimSpace(v) == "" {
+			return "(default)"
+		}
+		return v
+	}
+	if a.baseURL != b.baseURL {
+		fields = append(fields, fieldChange{"base-url", redactURL(a.baseURL), redactURL(b.baseURL)})
+	}
+	derived := map[string]func(o *options) string{
+		"base-dir":     func(o *options) string { return o.ArtifactID },
+		"package-name": func(o *options) string { return initializr.SanitizePackage(o.GroupID + "." + o.ArtifactID) },
+	}
+	for _, p := range sharedParams {
+		x, y := *p.value(&a), *p.value(&b)
+		if p.flag == "boot-version" {
+			x, y = initializr.NormalizeBootVersion(x), initializr.NormalizeBootVersion(y)
+		}
+		if x == y {
+			continue
+		}
+		if d, ok := derived[p.flag]; ok && x == d(&a) && y == d(&b) {
+			continue
+		}
+		fields = append(fields, fieldChange{p.flag, show(x), show(y)})
+	}
+	for _, id := range b.Dependencies {
+		if !slices.Contains(a.Dependencies, id) {
+			added = append(added, id)
+		}
+	}
+	for _, id := range a.Dependencies {
+		if !slices.Contains(b.Dependencies, id) {
+			removed = append(removed, id)
+		}
+	}
+	return fields, added, removed
+}
+
+// splitDiffArgs separates the flags of diff itself, those defined in fs,
+// from the two sides. A side written as a command line starts with "-" as
+// well, so any other argument, like "--dependencies web", is a side, as is
+// everything after "--".
+func splitDiffArgs(fs *flag.FlagSet, args []string) (flags, sides []string) {
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if a == "--" {
+			return flags, append(sides, args[i+1:]...)
+		}
+		name, _, hasValue := strings.Cut(strings.TrimLeft(a, "-"), "=")
+		f := fs.Lookup(name)
+		if !strings.HasPrefix(a, "-") || (f == nil && name != "h" && name != "help") {
+			sides = append(sides, a)
+			continue
+		}
+		flags = append(flags, a)
+		if f == nil || hasValue {
+			continue
+		}
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+			continue
+		}
+		if i+1 < len(args) {
+			i++
+			flags = append(flags, args[i])
+		}
+	}
+	return flags, sides
+}
+
+// runDiff compares two project configurations and, with --archives, the
+// projects they generate.
+func runDiff(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	archives := fs.Bool("archives", false, "Also generate both projects and diff their files")
+	configPath := fs.String("config", defaultConfigPath(), "JSON config file for both sides (each side's flags override its values)")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: spring-initializr-cli diff [--archives] <left> <right>\n\n")
+		fmt.Fprintf(os.Stderr, "Compares two project configurations. Each side is one of:\n")
+		fmt.Fprintf(os.Stderr, "  - a quoted command line, e.g. \"--dependencies web --java-version 21\"\n")
+		fmt.Fprintf(os.Stderr, "  - a preset file (see --preset)\n")
+		fmt.Fprintf(os.Stderr, "  - a %s file or a project directory holding one\n", descriptorName)
+		fmt.Fprintf(os.Stderr, "  - a start.spring.io share link or a /starter.zip?... URL\n")
+		fmt.Fprintf(os.Stderr, "Sides after -- are never read as flags of diff.\n\n")
+		fs.PrintDefaults()
+	}
+	flags, specs := splitDiffArgs(fs, args)
+	fs.Parse(flags)
+	specs = append(specs, fs.Args()...)
+	if len(specs) != 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	var config *string
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "config" {
+			config = configPath
+		}
+	})
+
+	var sides [2]options
+	for i, spec := range specs {
+		o, err := sideOptions(spec, config)
+		if err != nil {
+			return fmt.Errorf("diff: %s: %w", spec, err)
+		}
+		sides[i] = o
+	}
+
+	// Generating the projects fetches the server's metadata, which fills in
+	// the choices either side leaves to the server.
+	// Sides on the same server share its metadata.
+	var files [2]map[string]initializr.File
+	if *archives {
+		metas := map[string]*initializr.Metadata{}
+		for i := range sides {
+			o := &sides[i]
+			if err := o.prepareTransport(); err != nil {
+				return err
+			}
+			var p resolvedProject
+			var err error
+			if m, ok := metas[o.baseURL]; ok {
+				p, err = projectFor(*o, m)
+			} else {
+				p, err = resolveProject(ctx, *o)
+				metas[o.baseURL] = p.meta
+			}
+			if err != nil {
+				return err
+			}
+			if files[i], err = generateFiles(ctx, *o, p); err != nil {
+				return fmt.Errorf("diff: generating %s: %w", specs[i], err)
+			}
+			o.ProjectRequest = effectiveRequest(o.ProjectRequest, p.meta)
+		}
+	}
+
+	w := os.Stdout
+	fmt.Fprintf(w, "--- %s\n+++ %s\n", redactURL(specs[0]), redactURL(specs[1]))
+	fields, added, removed := compareOptions(sides[0], sides[1])
+	for _, f := range fields {
+		fmt.Fprintf(w, "%s: %s -> %s\n", f.name, f.from, f.to)
+	}
+	if len(added)+len(removed) > 0 {
+		fmt.Fprintln(w, "dependencies:")
+		for _, id := range added {
+			fmt.Fprintf(w, "+ %s\n", id)
+		}
+		for _, id := range removed {
+			fmt.Fprintf(w, "- %s\n", id)
+		}
+	}
+	if len(fields)+len(added)+len(removed) == 0 {
+		fmt.Fprintln(os.Stderr, "The configurations are the same")
+	}
+	if !*archives {
+		return nil
+	}
+
+	changes := compareFiles(files[0], files[1], nil)
+	if len(changes) == 0 {
+		fmt.Fprintln(os.Stderr, "The generated projects are the same")
+		return nil
+	}
+	fmt.Fprintln(w, "files:")
+	for _, c := range changes {
+		status := "modified"
+		switch {
+		case c.old == nil:
+			status = "added"
+		case c.new == nil:
+			status = "removed"
+		}
+		fmt.Fprintf(w, "  %-8s %s\n", status, c.path)
+	}
+	for _, c := range changes {
+		fmt.Fprintln(w)
+		writeChange(w, c)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mikoto2000/spring-initializr-cli/initializrtest"
+)
+
+func TestSideArgs(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"team.json":             `{"project":{"javaVersion":"21"}}`,
+		"app/" + descriptorName: `{"version":1,"request":{"artifactId":"app"}}`,
+	})
+	cases := []struct{ spec, want string }{
+		{"", ""},
+		{commandName + " --java-version 21 --name 'My App'", "--java-version 21 --name My App"},
+		{"--dependencies web", "--dependencies web"},
+		{"https://start.spring.io/#!type=maven-project", "--from-url https://start.spring.io/#!type=maven-project"},
+		{filepath.Join(dir, "app"), "--from-descriptor " + filepath.Join(dir, "app")},
+		{filepath.Join(dir, "app", descriptorName), "--from-descriptor " + filepath.Join(dir, "app", descriptorName)},
+		{filepath.Join(dir, "team.json"), "--preset " + filepath.Join(dir, "team.json")},
+	}
+	for _, c := range cases {
+		args, err := sideArgs(c.spec)
+		if err != nil {
+			t.Fatalf("%q: %v", c.spec, err)
+		}
+		if got := strings.Join(args, " "); got != c.want {
+			t.Errorf("%q: args = %q, want %q", c.spec, got, c.want)
+		}
+	}
+	if _, err := sideArgs(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("missing file accepted")
+	}
+}
+
+func TestDiffOptions(t *testing.T) {
+	dir := t.TempDir()
+	preset := filepath.Join(dir, "team.json")
+	os.WriteFile(preset, []byte(`{"project":{"groupId":"com.acme","artifactId":"orders","dependencies":["web","lombok"]}}`), 0o644)
+
+	var err error
+	out := captureStdout(t, func() {
+		err = runDiff(context.Background(), []string{"--config", "", preset,
+			"--group-id com.acme --artifact-id billing --boot-version 3.5.5.RELEASE --dependencies web,security"})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"artifact-id: orders -> billing\n",
+		"boot-version: (default) -> 3.5.5\n",
+		"dependencies:\n+ security\n- lombok\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("output lacks %q:\n%s", want, out)
+		}
+	}
+	// The base directory and package follow the artifact ID on both sides.
+	if strings.Contains(out, "base-dir") || strings.Contains(out, "package-name") {
+		t.Fatalf("derived fields reported:\n%s", out)
+	}
+}
+
+func TestDiffCommandLineSidesWithoutConfig(t *testing.T) {
+	// No config file exists and diff is given no --config.
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	var err error
+	out := captureStdout(t, func() {
+		err = runDiff(context.Background(), []string{"--dependencies web", "--dependencies web,security --java-version 21"})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "java-version: (default) -> 21\n") || !strings.Contains(out, "dependencies:\n+ security\n") {
+		t.Fatalf("output:\n%s", out)
+	}
+}
+
+func TestSplitDiffArgs(t *testing.T) {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	fs.Bool("archives", false, "")
+	fs.String("config", "", "")
+	flags, sides := splitDiffArgs(fs, []string{"--archives", "--config", "team.json", "--java-version 21", "--", "--archives"})
+	if strings.Join(flags, "|") != "--archives|--config|team.json" || strings.Join(sides, "|") != "--java-version 21|--archives" {
+		t.Fatalf("flags = %q, sides = %q", flags, sides)
+	}
+}
+
+func TestDiffArchives(t *testing.T) {
+	srv := initializrtest.NewServer()
+	defer srv.Close()
+	generateOldProject(t, srv.URL)
+
+	var err error
+	out := captureStdout(t, func() {
+		err = runDiff(context.Background(), []string{"--config", "", "--archives", "demo",
+			"--base-url " + srv.URL + " --boot-version 3.4.9 --dependencies web --configuration-file-format yaml"})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"configuration-file-format: properties -> yaml\n",
+		"files:\n  removed  src/main/resources/application.properties\n  added    src/main/resources/application.yaml\n",
+		"--- a/src/main/resources/application.properties\n+++ /dev/null\n",
+		"+++ b/src/main/resources/application.yaml\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("output lacks %q:\n%s", want, out)
+		}
+	}
+	// Java 17 is the server's default, so leaving it out changes nothing.
+	if strings.Contains(out, "pom.xml") || strings.Contains(out, "java-version") {
+		t.Fatalf("unchanged setting or file reported:\n%s", out)
+	}
+}
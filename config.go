@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/mikoto2000/spring-initializr-cli/initializr"
+)
+
+// fileConfig is the JSON configuration file. Flags given on the command line
+// take precedence over its values.
+type fileConfig struct {
+	BaseURL               string `json:"baseUrl,omitempty"`
+	Timeout               int    `json:"timeout,omitempty"`
+	Proxy                 string `json:"proxy,omitempty"`
+	CACert                string `json:"caCert,omitempty"`
+	ClientCert            string `json:"clientCert,omitempty"`
+	ClientKey             string `json:"clientKey,omitempty"`
+	InsecureSkipTLSVerify bool   `json:"insecureSkipTlsVerify,omitempty"`
+
+	TokenEnv  string            `json:"tokenEnv,omitempty"`
+	TokenFile string            `json:"tokenFile,omitempty"`
+	NetrcFile string            `json:"netrcFile,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+
+	// Project holds default project settings, e.g. a team preset.
+	Project initializr.ProjectRequest `json:"project,omitzero"`
+
+	// MavenMirror and GradleMirror point generated projects at internal
+	// mirrors.
+	MavenMirror  string `json:"mavenMirror,omitempty"`
+	GradleMirror string `json:"gradleMirror,omitempty"`
+
+	// Overlays are template directories copied onto extracted projects
+	// before those given with --overlay.
+	Overlays        []string `json:"overlays,omitempty"`
+	OverlayConflict string   `json:"overlayConflict,omitempty"`
+}
+
+// layer returns c with every value set in top written over it. Headers
+// and project settings are merged key by key.
+func (c fileConfig) layer(top fileConfig) (fileConfig, error) {
+	var base, over map[string]any
+	for _, x := range []struct {
+		v   fileConfig
+		dst *map[string]any
+	}{{c, &base}, {top, &over}} {
+		b, err := json.Marshal(x.v)
+		if err != nil {
+			return c, err
+		}
+		if err := json.Unmarshal(b, x.dst); err != nil {
+			return c, err
+		}
+	}
+	for k, v := range over {
+		if m, ok := v.(map[string]any); ok {
+			if bm, ok := base[k].(map[string]any); ok {
+				maps.Copy(bm, m)
+				continue
+			}
+		}
+		base[k] = v
+	}
+	b, err := json.Marshal(base)
+	if err != nil {
+		return c, err
+	}
+	var out fileConfig
+	return out, json.Unmarshal(b, &out)
+}
+
+// defaultConfigPath returns <user config dir>/spring-initializr-cli/config.json,
+// or "" when the user config directory is unknown.
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "spring-initializr-cli", "config.json")
+}
+
+// loadConfig reads the config file at path. A missing file is an error only
+// when the path was given explicitly. Relative file paths in the config are
+// resolved against the config file's directory.
+func loadConfig(path string, explicit bool) (fileConfig, error) {
+	var c fileConfig
+	if path == "" {
+		return c, nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if !explicit && errors.Is(err, fs.ErrNotExist) {
+			return c, nil
+		}
+		return c, err
+	}
+	if err := json.Unmarshal(b, &c); err != nil {
+		return c, fmt.Errorf("%s: %w", path, err)
+	}
+	dir := filepath.Dir(path)
+	paths := []*string{&c.CACert, &c.ClientCert, &c.ClientKey, &c.TokenFile, &c.NetrcFile}
+	for i := range c.Overlays {
+		paths = append(paths, &c.Overlays[i])
+	}
+	for _, p := range paths {
+		if *p != "" && !filepath.IsAbs(*p) {
+			*p = filepath.Join(dir, *p)
+		}
+	}
+	return c, nil
+}
+
+// applyConfig loads the config file named by the "config" flag of fs, with
+// the file of the "preset" flag, if fs has one, layered over it, and copies
+// their values into o for every flag not set on the command line.
+func applyConfig(fs *flag.FlagSet, configPath string, o *options) error {
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	c, err := loadConfig(configPath, set["config"])
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	if f := fs.Lookup("preset"); f != nil && f.Value.String() != "" {
+		p, err := loadConfig(f.Value.String(), true)
+		if err != nil {
+			return fmt.Errorf("preset: %w", err)
+		}
+		if c, err = c.layer(p); err != nil {
+			return fmt.Errorf("preset: %w", err)
+		}
+	}
+	str := func(flagName string, dst *string, v string) {
+		if v != "" && !set[flagName] {
+			*dst = v
+		}
+	}
+	str("base-url", &o.baseURL, c.BaseURL)
+	str("proxy", &o.transport.proxy, c.Proxy)
+	str("ca-cert", &o.transport.caCert, c.CACert)
+	str("client-cert", &o.transport.clientCert, c.ClientCert)
+	str("client-key", &o.transport.clientKey, c.ClientKey)
+	if c.Timeout > 0 && !set["timeout"] {
+		o.timeout = c.Timeout
+	}
+	if c.InsecureSkipTLSVerify && !set["insecure-skip-tls-verify"] {
+		o.transport.insecureSkipTLSVerify = true
+	}
+	str("token-env", &o.auth.tokenEnv, c.TokenEnv)
+	str("token-file", &o.auth.tokenFile, c.TokenFile)
+	str("netrc-file", &o.auth.netrcFile, c.NetrcFile)
+	// Config headers come first so --header can override them by name.
+	names := make([]string, 0, len(c.Headers))
+	for name := range c.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var headers headerList
+	for _, name := range names {
+		if err := headers.Set(name + ": " + c.Headers[name]); err != nil {
+			return fmt.Errorf("config: %w", err)
+		}
+	}
+	o.auth.headers = append(headers, o.auth.headers...)
+	str("maven-mirror", &o.mirrors.maven, c.MavenMirror)
+	str("gradle-mirror", &o.mirrors.gradle, c.GradleMirror)
+	o.overlay.configured = c.Overlays
+	str("overlay-conflict", &o.overlay.conflict, c.OverlayConflict)
+
+	project := options{ProjectRequest: c.Project}
+	for _, p := range sharedParams {
+		str(p.flag, p.value(o), *p.value(&project))
+	}
+	if len(c.Project.Dependencies) > 0 && !set["dependencies"] {
+		o.Dependencies = c.Project.Dependencies
+	}
+	return nil
+}
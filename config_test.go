@@ -0,0 +1,88 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestApplyConfigFlagsTakePrecedence(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	os.WriteFile(path, []byte(`{
+		"baseUrl": "https://initializr.internal",
+		"timeout": 5,
+		"caCert": "certs/ca.pem",
+		"clientCert": "/abs/client.pem",
+		"insecureSkipTlsVerify": true
+	}`), 0o644)
+
+	var o options
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.StringVar(&o.baseURL, "base-url", defaultBaseURL, "")
+	fs.IntVar(&o.timeout, "timeout", 60, "")
+	configPath := fs.String("config", "", "")
+	registerTransportFlags(fs, &o.transport)
+	if err := fs.Parse([]string{"--config", path, "--timeout", "9"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyConfig(fs, *configPath, &o); err != nil {
+		t.Fatal(err)
+	}
+
+	if o.baseURL != "https://initializr.internal" || o.timeout != 9 {
+		t.Fatalf("baseURL=%q timeout=%d", o.baseURL, o.timeout)
+	}
+	if o.transport.caCert != filepath.Join(dir, "certs", "ca.pem") || o.transport.clientCert != "/abs/client.pem" {
+		t.Fatalf("paths not resolved: %+v", o.transport)
+	}
+	if !o.transport.insecureSkipTLSVerify {
+		t.Fatal("insecureSkipTlsVerify not applied")
+	}
+}
+
+func TestLoadConfigMissingFile(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "nope.json")
+	if _, err := loadConfig(missing, false); err != nil {
+		t.Fatalf("default config missing: %v", err)
+	}
+	if _, err := loadConfig(missing, true); err == nil {
+		t.Fatal("explicit config missing should fail")
+	}
+}
+
+func TestPresetLayersOverConfig(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, "config.json")
+	preset := filepath.Join(dir, "team.json")
+	os.WriteFile(config, []byte(`{
+		"baseUrl": "https://initializr.internal",
+		"timeout": 5,
+		"headers": {"X-Team": "all", "X-Trace": "1"},
+		"project": {"groupId": "com.acme", "javaVersion": "17"}
+	}`), 0o644)
+	os.WriteFile(preset, []byte(`{
+		"headers": {"X-Team": "payments"},
+		"project": {"javaVersion": "21", "dependencies": ["web", "actuator"]}
+	}`), 0o644)
+
+	var o options
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	extra := registerFlags(fs, &o)
+	if err := fs.Parse([]string{"--config", config, "--preset", preset, "--group-id", "org.example"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyConfig(fs, *extra.configPath, &o); err != nil {
+		t.Fatal(err)
+	}
+	if o.baseURL != "https://initializr.internal" || o.timeout != 5 {
+		t.Fatalf("baseURL=%q timeout=%d", o.baseURL, o.timeout)
+	}
+	if o.GroupID != "org.example" || o.JavaVersion != "21" || len(o.Dependencies) != 2 {
+		t.Fatalf("project = %+v", o.ProjectRequest)
+	}
+	if got := o.auth.headers.String(); got != "X-Team: payments, X-Trace: 1" {
+		t.Fatalf("headers = %q", got)
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptrace"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// debugBodyLimit is how much of a JSON response body --debug-http prints.
+const debugBodyLimit = 2048
+
+// alwaysRedacted lists the headers whose values --debug-http never prints.
+var alwaysRedacted = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// debugTransport logs every request and response passing through it:
+// headers with secrets redacted, a timing breakdown and the start of JSON
+// bodies. It sits below authTransport so it sees the headers actually sent.
+type debugTransport struct {
+	next   http.RoundTripper
+	out    io.Writer
+	redact map[string]bool // canonical header names
+
+	mu  sync.Mutex // serializes writes to out and guards seq
+	seq int
+}
+
+func newDebugTransport(next http.RoundTripper, out io.Writer, redact []string) *debugTransport {
+	t := &debugTransport{next: next, out: out, redact: map[string]bool{}}
+	for _, name := range append(append([]string{}, alwaysRedacted...), redact...) {
+		t.redact[http.CanonicalHeaderKey(name)] = true
+	}
+	return t
+}
+
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	t.seq++
+	id := t.seq
+	t.mu.Unlock()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--> #%d %s %s\n", id, req.Method, redactURL(req.URL.String()))
+	t.writeHeaders(&b, req.Header)
+	t.write(b.String())
+
+	tm := &traceTimes{start: time.Now()}
+	resp, err := t.next.RoundTrip(req.WithContext(httptrace.WithClientTrace(req.Context(), tm.trace())))
+	b.Reset()
+	if err != nil {
+		fmt.Fprintf(&b, "<-- #%d error: %v (%s)\n", id, err, tm.summary())
+		t.write(b.String())
+		return nil, err
+	}
+	fmt.Fprintf(&b, "<-- #%d %s (%s)\n", id, resp.Status, tm.summary())
+	t.writeHeaders(&b, resp.Header)
+	if isJSON(resp.Header.Get("Content-Type")) {
+		t.peekBody(&b, resp)
+	}
+	t.write(b.String())
+	return resp, nil
+}
+
+func (t *debugTransport) write(s string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	io.WriteString(t.out, s)
+}
+
+func (t *debugTransport) writeHeaders(b *strings.Builder, h http.Header) {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, v := range h[name] {
+			if t.redact[http.CanonicalHeaderKey(name)] {
+				v = "[REDACTED]"
+			}
+			fmt.Fprintf(b, "    %s: %s\n", name, v)
+		}
+	}
+}
+
+// peekBody prints up to debugBodyLimit bytes of resp's body and puts them
+// back so the caller still reads the whole body.
+func (t *debugTransport) peekBody(b *strings.Builder, resp *http.Response) {
+	head, err := io.ReadAll(io.LimitReader(resp.Body, debugBodyLimit+1))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(head), resp.Body), resp.Body}
+	if err != nil {
+		fmt.Fprintf(b, "    (body unreadable: %v)\n", err)
+		return
+	}
+	if len(head) > debugBodyLimit {
+		fmt.Fprintf(b, "    %s... (truncated at %d bytes)\n", head[:debugBodyLimit], debugBodyLimit)
+		return
+	}
+	fmt.Fprintf(b, "    %s\n", head)
+}
+
+func isJSON(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mt == "application/json" || strings.HasSuffix(mt, "+json"))
+}
+
+// traceTimes collects the phases of one request from httptrace callbacks,
+// which may run on other goroutines.
+type traceTimes struct {
+	mu                            sync.Mutex
+	start                         time.Time
+	dnsStart, connStart, tlsStart time.Time
+	dns, connect, tls, ttfb       time.Duration
+	reused                        bool
+}
+
+func (tm *traceTimes) trace() *httptrace.ClientTrace {
+	since := func(from time.Time) time.Duration { return time.Since(from).Round(time.Microsecond) }
+	lock := func(f func()) {
+		tm.mu.Lock()
+		defer tm.mu.Unlock()
+		f()
+	}
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { lock(func() { tm.dnsStart = time.Now() }) },
+		DNSDone:  func(httptrace.DNSDoneInfo) { lock(func() { tm.dns = since(tm.dnsStart) }) },
+		ConnectStart: func(string, string) {
+			lock(func() {
+				if tm.connStart.IsZero() {
+					tm.connStart = time.Now()
+				}
+			})
+		},
+		ConnectDone:          func(string, string, error) { lock(func() { tm.connect = since(tm.connStart) }) },
+		TLSHandshakeStart:    func() { lock(func() { tm.tlsStart = time.Now() }) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { lock(func() { tm.tls = since(tm.tlsStart) }) },
+		GotConn:              func(info httptrace.GotConnInfo) { lock(func() { tm.reused = info.Reused }) },
+		GotFirstResponseByte: func() { lock(func() { tm.ttfb = since(tm.start) }) },
+	}
+}
+
+func (tm *traceTimes) summary() string {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	var parts []string
+	if tm.reused {
+		parts = append(parts, "reused connection")
+	}
+	for _, p := range []struct {
+		name string
+		d    time.Duration
+	}{{"dns", tm.dns}, {"connect", tm.connect}, {"tls", tm.tls}, {"ttfb", tm.ttfb}} {
+		if p.d > 0 {
+			parts = append(parts, p.name+" "+p.d.String())
+		}
+	}
+	return strings.Join(append(parts, "total "+time.Since(tm.start).Round(time.Microsecond).String()), ", ")
+}
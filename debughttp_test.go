@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mikoto2000/spring-initializr-cli/initializrtest"
+)
+
+func TestDebugTransportRedactsAndTruncates(t *testing.T) {
+	long := `{"data":"` + strings.Repeat("x", 3*debugBodyLimit) + `"}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/starter.zip" {
+			w.Header().Set("Content-Type", "application/zip")
+			w.Write([]byte("PK-binary"))
+			return
+		}
+		w.Header().Set("Content-Type", "application/vnd.initializr.v2.3+json")
+		w.Header().Set("Set-Cookie", "session=cookie-secret")
+		io.WriteString(w, long)
+	}))
+	defer srv.Close()
+
+	var log bytes.Buffer
+	creds := &credentials{host: strings.TrimPrefix(srv.URL, "http://"), bearer: "token-secret",
+		headers: http.Header{"X-Api-Key": {"key-secret"}}, headerNames: []string{"X-Api-Key"}}
+	rt := &authTransport{creds: creds, next: newDebugTransport(http.DefaultTransport, &log, creds.headerNames)}
+	client := &http.Client{Transport: rt}
+
+	resp, err := client.Get(srv.URL + "/metadata/client")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != long {
+		t.Fatalf("body altered: got %d bytes, want %d", len(body), len(long))
+	}
+	resp, err = client.Get(srv.URL + "/starter.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	out := log.String()
+	for _, secret := range []string{"token-secret", "key-secret", "cookie-secret"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("log leaks %q:\n%s", secret, out)
+		}
+	}
+	for _, want := range []string{
+		"--> #1 GET " + srv.URL + "/metadata/client",
+		"Authorization: [REDACTED]",
+		"X-Api-Key: [REDACTED]",
+		"<-- #1 200 OK (",
+		"ttfb ",
+		"(truncated at 2048 bytes)",
+		"--> #2 GET " + srv.URL + "/starter.zip",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log lacks %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "PK-binary") {
+		t.Errorf("non-JSON body logged:\n%s", out)
+	}
+}
+
+func TestDebugTransportLogsErrors(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+
+	var log bytes.Buffer
+	if err := get(newDebugTransport(http.DefaultTransport, &log, nil), url); err == nil {
+		t.Fatal("expected a connection error")
+	}
+	if !strings.Contains(log.String(), "<-- #1 error: ") {
+		t.Fatalf("log = %q", log.String())
+	}
+}
+
+func TestRunDebugHTTPCoversDownload(t *testing.T) {
+	srv := initializrtest.NewServer()
+	defer srv.Close()
+
+	var log bytes.Buffer
+	o := mockOptions(t, srv.URL)
+	o.debugHTTP, o.debugOut = true, &log
+	if err := run(t.Context(), o); err != nil {
+		t.Fatal(err)
+	}
+	// The metadata lookup for the project URL comes first, then the download.
+	if !strings.Contains(log.String(), "--> #1 GET "+srv.URL+"/\n") || !strings.Contains(log.String(), "--> #2 GET "+srv.URL+"/starter.zip?") || !strings.Contains(log.String(), "<-- #2 200 OK") {
+		t.Fatalf("log = %q", log.String())
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mikoto2000/spring-initializr-cli/initializr"
+)
+
+// descriptorName is the descriptor file written into extracted projects.
+const descriptorName = ".initializr.json"
+
+// descriptorVersion is the format version of the descriptor file.
+const descriptorVersion = 1
+
+// descriptor records how a project was generated so that --from-descriptor
+// can send the identical request later. The request has every value spelled
+// out, including those that came from CLI or server defaults.
+type descriptor struct {
+	Version     int    `json:"version"`
+	Generator   string `json:"generator"`
+	GeneratedAt string `json:"generatedAt"`
+	BaseURL     string `json:"baseUrl"`
+	URL         string `json:"url"`
+	// MetadataFingerprint identifies the server metadata the project was
+	// generated against; empty when the metadata was unavailable.
+	MetadataFingerprint string                    `json:"metadataFingerprint,omitempty"`
+	Request             initializr.ProjectRequest `json:"request"`
+
+	// MavenMirror and GradleMirror are the mirrors the extracted project
+	// was rewritten to use; empty when it uses the default repositories.
+	MavenMirror  string `json:"mavenMirror,omitempty"`
+	GradleMirror string `json:"gradleMirror,omitempty"`
+}
+
+// effectiveRequest returns r with empty choices filled from the server's
+// defaults in m and the Boot version normalized.
+func effectiveRequest(r initializr.ProjectRequest, m *initializr.Metadata) initializr.ProjectRequest {
+	if m != nil {
+		for _, f := range []struct {
+			dst *string
+			sel initializr.SingleSelect
+		}{
+			{&r.Type, m.Type},
+			{&r.Language, m.Language},
+			{&r.BootVersion, m.BootVersion},
+			{&r.Packaging, m.Packaging},
+			{&r.JavaVersion, m.JavaVersion},
+			{&r.ConfigurationFileFormat, m.ConfigurationFileFormat},
+		} {
+			if *f.dst == "" {
+				*f.dst = f.sel.DefaultID()
+			}
+		}
+	}
+	r.BootVersion = initializr.NormalizeBootVersion(r.BootVersion)
+	return r
+}
+
+// writeDescriptor writes the descriptor for o, generated from p, into the
+// project directory dir.
+func writeDescriptor(dir string, o options, p resolvedProject) error {
+	d := descriptor{
+		Version:     descriptorVersion,
+		Generator:   commandName + " " + version,
+		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+		BaseURL:     stripUserinfo(o.baseURL),
+		URL:         stripUserinfo(p.url),
+		Request:     effectiveRequest(o.ProjectRequest, p.meta),
+
+		MavenMirror:  stripUserinfo(o.mirrors.maven),
+		GradleMirror: stripUserinfo(o.mirrors.gradle),
+	}
+	if p.meta != nil {
+		d.MetadataFingerprint = p.meta.Fingerprint()
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false) // keep the & in URLs readable
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(d); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, descriptorName), buf.Bytes(), 0o644)
+}
+
+// metadataChanged reports whether the server's metadata in p differs from
+// the metadata the descriptor given with --from-descriptor was written
+// against.
+func metadataChanged(o options, p resolvedProject) bool {
+	return o.expectFingerprint != "" && p.meta != nil && p.meta.Fingerprint() != o.expectFingerprint
+}
+
+// loadDescriptor reads a descriptor file. A directory means the descriptor
+// inside it.
+func loadDescriptor(path string) (descriptor, error) {
+	var d descriptor
+	if fi, err := os.Stat(path); err == nil && fi.IsDir() {
+		path = filepath.Join(path, descriptorName)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return d, err
+	}
+	if err := json.Unmarshal(b, &d); err != nil {
+		return d, fmt.Errorf("%s: %w", path, err)
+	}
+	if d.Version != descriptorVersion {
+		return d, fmt.Errorf("%s: unsupported descriptor version %d", path, d.Version)
+	}
+	if d.BaseURL == "" {
+		return d, fmt.Errorf("%s: no baseUrl", path)
+	}
+	return d, nil
+}
+
+// applyDescriptor copies a descriptor's request into o for every flag not
+// set on the command line, so explicit flags win over the recorded values.
+// The recorded mirrors are copied only when extracted is set, since they
+// apply to extracted projects alone.
+func applyDescriptor(fs *flag.FlagSet, path string, o *options, extracted bool) error {
+	d, err := loadDescriptor(path)
+	if err != nil {
+		return fmt.Errorf("from-descriptor: %w", err)
+	}
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if set["from-url"] {
+		return errors.New("--from-descriptor and --from-url cannot be combined")
+	}
+
+	if !set["base-url"] {
+		o.baseURL = d.BaseURL
+	}
+	recorded := options{ProjectRequest: d.Request}
+	for _, p := range sharedParams {
+		if !set[p.flag] {
+			*p.value(o) = *p.value(&recorded)
+		}
+	}
+	if !set["dependencies"] {
+		o.Dependencies = d.Request.Dependencies
+	}
+	if extracted && d.MavenMirror != "" && !set["maven-mirror"] {
+		o.mirrors.maven = d.MavenMirror
+	}
+	if extracted && d.GradleMirror != "" && !set["gradle-mirror"] {
+		o.mirrors.gradle = d.GradleMirror
+	}
+	o.expectFingerprint = d.MetadataFingerprint
+	return nil
+}
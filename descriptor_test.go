@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mikoto2000/spring-initializr-cli/initializr"
+	"github.com/mikoto2000/spring-initializr-cli/initializrtest"
+)
+
+func TestDescriptorRegeneratesIdenticalRequest(t *testing.T) {
+	srv := initializrtest.NewServer()
+	defer srv.Close()
+	o := mockOptions(t, srv.URL)
+	o.Dependencies = []string{"web", "actuator"}
+	o.extract = true
+	o.writeDescriptor = true
+	if _, err := download(context.Background(), o, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := loadDescriptor(o.BaseDir) // a project directory works too
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.BaseURL != srv.URL || !strings.HasPrefix(d.MetadataFingerprint, "sha256:") || !strings.HasPrefix(d.URL, srv.URL+"/starter.zip?") {
+		t.Fatalf("descriptor = %+v", d)
+	}
+	// Server defaults are resolved and recorded.
+	if d.Request.BootVersion != "3.5.5" || d.Request.JavaVersion != "17" || d.Request.ConfigurationFileFormat != "properties" {
+		t.Fatalf("request = %+v", d.Request)
+	}
+
+	// Later, with different CLI defaults, the descriptor restores every value.
+	var later options
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	registerFlags(fs, &later)
+	later.JavaVersion, later.Language = "25", "kotlin" // as if the defaults had changed
+	if err := applyDescriptor(fs, filepath.Join(o.BaseDir, descriptorName), &later, false); err != nil {
+		t.Fatal(err)
+	}
+	if later.baseURL != srv.URL || later.ProjectRequest.Values().Encode() != d.Request.Values().Encode() {
+		t.Fatalf("regenerated request differs:\n got %s\nwant %s", later.ProjectRequest.Values().Encode(), d.Request.Values().Encode())
+	}
+	if later.expectFingerprint != d.MetadataFingerprint {
+		t.Fatalf("fingerprint not carried over")
+	}
+
+	// Explicit flags still win.
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	registerFlags(fs, &later)
+	if err := fs.Parse([]string{"--artifact-id", "renamed", "--dependencies", "webflux"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyDescriptor(fs, o.BaseDir, &later, false); err != nil {
+		t.Fatal(err)
+	}
+	if later.ArtifactID != "renamed" || strings.Join(later.Dependencies, ",") != "webflux" || later.GroupID != "com.example" {
+		t.Fatalf("flags lost: %+v", later.ProjectRequest)
+	}
+}
+
+func TestDescriptorOmitsCredentials(t *testing.T) {
+	srv := initializrtest.NewServer()
+	defer srv.Close()
+	o := mockOptions(t, strings.Replace(srv.URL, "http://", "http://jane:s3cret@", 1))
+	o.extract = true
+	o.writeDescriptor = true
+	if _, err := download(context.Background(), o, nil); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(filepath.Join(o.BaseDir, descriptorName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := string(b); strings.Contains(s, "jane") || strings.Contains(s, "s3cret") || strings.Contains(s, "REDACTED") {
+		t.Fatalf("credentials kept:\n%s", s)
+	}
+	if d, err := loadDescriptor(o.BaseDir); err != nil || d.BaseURL != srv.URL {
+		t.Fatalf("baseUrl = %q, %v", d.BaseURL, err)
+	}
+}
+
+func TestEffectiveRequestWithoutMetadata(t *testing.T) {
+	r := effectiveRequest(initializr.ProjectRequest{BootVersion: "3.5.5.RELEASE"}, nil)
+	if r.BootVersion != "3.5.5" || r.JavaVersion != "" {
+		t.Fatalf("request = %+v", r)
+	}
+}
+
+func TestLoadDescriptorErrors(t *testing.T) {
+	dir := t.TempDir()
+	for name, body := range map[string]string{
+		"version.json": `{"version": 2, "baseUrl": "https://start.spring.io"}`,
+		"nobase.json":  `{"version": 1}`,
+		"broken.json":  `{`,
+	} {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, []byte(body), 0o644)
+		if _, err := loadDescriptor(path); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestWriteDescriptorNeedsExtract(t *testing.T) {
+	o := mockOptions(t, "http://initializr.invalid")
+	o.writeDescriptor = true
+	if err := run(context.Background(), o); err == nil || !strings.Contains(err.Error(), "--extract") {
+		t.Fatalf("err = %v", err)
+	}
+}
@@ -0,0 +1,388 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mikoto2000/spring-initializr-cli/initializr"
+)
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+// maxDiffCells bounds the LCS table of a line diff; larger changes are shown
+// as a whole replacement.
+const maxDiffCells = 4 << 20
+
+// edit is one line of a line diff: ' ' kept, '-' removed or '+' added.
+type edit struct {
+	op   byte
+	line string // including its newline, if any
+}
+
+// splitLines splits s after each newline. A last line without one is kept
+// as is.
+func splitLines(s string) []string {
+	var lines []string
+	for s != "" {
+		i := strings.IndexByte(s, '\n')
+		if i < 0 {
+			lines = append(lines, s)
+			break
+		}
+		lines = append(lines, s[:i+1])
+		s = s[i+1:]
+	}
+	return lines
+}
+
+// diffLines returns the edits that turn a into b.
+func diffLines(a, b []string) []edit {
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+	var out []edit
+	for _, l := range a[:pre] {
+		out = append(out, edit{' ', l})
+	}
+	out = append(out, lcsEdits(a[pre:len(a)-suf], b[pre:len(b)-suf])...)
+	for _, l := range a[len(a)-suf:] {
+		out = append(out, edit{' ', l})
+	}
+	return out
+}
+
+// lcsEdits diffs a and b through their longest common subsequence.
+func lcsEdits(a, b []string) []edit {
+	n, m := len(a), len(b)
+	var out []edit
+	if n == 0 || m == 0 || n*m > maxDiffCells {
+		for _, l := range a {
+			out = append(out, edit{'-', l})
+		}
+		for _, l := range b {
+			out = append(out, edit{'+', l})
+		}
+		return out
+	}
+	// lcs[i][j] is the LCS length of a[i:] and b[j:].
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			out = append(out, edit{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			out = append(out, edit{'-', a[i]})
+			i++
+		default:
+			out = append(out, edit{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		out = append(out, edit{'-', a[i]})
+	}
+	for ; j < m; j++ {
+		out = append(out, edit{'+', b[j]})
+	}
+	return out
+}
+
+// hunk is a run of nearby changes with their surrounding context.
+type hunk struct {
+	oldStart, newStart int // 0-based index of the first line on each side
+	edits              []edit
+}
+
+// lines returns the hunk's lines on the old (' ' and '-') or new (' ' and
+// '+') side.
+func (h hunk) lines(side byte) []string {
+	var out []string
+	for _, e := range h.edits {
+		if e.op == ' ' || e.op == side {
+			out = append(out, e.line)
+		}
+	}
+	return out
+}
+
+// header renders the "@@ -l,s +l,s @@" line.
+func (h hunk) header() string {
+	span := func(start, n int) string {
+		switch n {
+		case 0:
+			return fmt.Sprintf("%d,0", start)
+		case 1:
+			return fmt.Sprint(start + 1)
+		}
+		return fmt.Sprintf("%d,%d", start+1, n)
+	}
+	return fmt.Sprintf("@@ -%s +%s @@", span(h.oldStart, len(h.lines('-'))), span(h.newStart, len(h.lines('+'))))
+}
+
+// makeHunks groups edits into hunks with diffContext lines of context,
+// merging changes whose contexts would overlap.
+func makeHunks(edits []edit) []hunk {
+	oldPos := make([]int, len(edits)+1)
+	newPos := make([]int, len(edits)+1)
+	for k, e := range edits {
+		oldPos[k+1], newPos[k+1] = oldPos[k], newPos[k]
+		if e.op != '+' {
+			oldPos[k+1]++
+		}
+		if e.op != '-' {
+			newPos[k+1]++
+		}
+	}
+	var hs []hunk
+	for k := 0; k < len(edits); {
+		if edits[k].op == ' ' {
+			k++
+			continue
+		}
+		end := k // last change in the hunk
+		for next := end + 1; next < len(edits) && next-end <= 2*diffContext+1; next++ {
+			if edits[next].op != ' ' {
+				end = next
+			}
+		}
+		from, to := max(0, k-diffContext), min(len(edits), end+1+diffContext)
+		hs = append(hs, hunk{oldStart: oldPos[from], newStart: newPos[from], edits: edits[from:to]})
+		k = end + 1
+	}
+	return hs
+}
+
+// hunkResult is the outcome of applying one hunk.
+type hunkResult int
+
+const (
+	hunkApplied hunkResult = iota
+	hunkPresent            // the file already has the new lines
+	hunkConflict
+)
+
+// applyHunks applies hs to lines. Each hunk is looked for near its recorded
+// position so that edits elsewhere in the file do not get in the way; a
+// hunk whose old lines cannot be found conflicts and is skipped.
+func applyHunks(lines []string, hs []hunk) ([]string, []hunkResult) {
+	out := slices.Clone(lines)
+	results := make([]hunkResult, len(hs))
+	offset, floor := 0, 0 // drift from the original positions; end of the last hunk
+	for k, h := range hs {
+		before, after := h.lines('-'), h.lines('+')
+		near := h.oldStart + offset
+		at := findLines(out, before, near, floor)
+		switch {
+		case at >= 0:
+			out = slices.Replace(out, at, at+len(before), after...)
+			results[k] = hunkApplied
+		default:
+			if at = findLines(out, after, near, floor); at < 0 {
+				results[k] = hunkConflict
+				continue
+			}
+			results[k] = hunkPresent
+		}
+		offset = at + len(after) - h.oldStart - len(before)
+		floor = at + len(after)
+	}
+	return out, results
+}
+
+// findLines returns the position of want in lines closest to near, not
+// before floor, or -1.
+func findLines(lines, want []string, near, floor int) int {
+	last := len(lines) - len(want)
+	if last < floor {
+		return -1
+	}
+	near = min(max(near, floor), last)
+	for d := 0; near-d >= floor || near+d <= last; d++ {
+		for _, at := range []int{near - d, near + d} {
+			if at >= floor && at <= last && slices.Equal(lines[at:at+len(want)], want) {
+				return at
+			}
+		}
+	}
+	return -1
+}
+
+// fileChange is how one file differs between two generated projects.
+type fileChange struct {
+	path     string
+	old, new *initializr.File // nil when the file is absent on that side
+	hunks    []hunk           // nil for binary files
+}
+
+func (c fileChange) binary() bool {
+	return (c.old != nil && isBinary(c.old.Data)) || (c.new != nil && isBinary(c.new.Data))
+}
+
+func isBinary(b []byte) bool {
+	return bytes.IndexByte(b, 0) >= 0 || !utf8.Valid(b)
+}
+
+// compareFiles lists the files that differ between a and b, sorted by path.
+// When keep is not nil, only paths it accepts are compared.
+func compareFiles(a, b map[string]initializr.File, keep func(path string) bool) []fileChange {
+	var paths []string
+	for p := range a {
+		paths = append(paths, p)
+	}
+	for p := range b {
+		if _, ok := a[p]; !ok {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+
+	var changes []fileChange
+	for _, p := range paths {
+		if keep != nil && !keep(p) {
+			continue
+		}
+		c := fileChange{path: p}
+		if f, ok := a[p]; ok {
+			c.old = &f
+		}
+		if f, ok := b[p]; ok {
+			c.new = &f
+		}
+		if c.old != nil && c.new != nil && bytes.Equal(c.old.Data, c.new.Data) {
+			continue
+		}
+		if !c.binary() {
+			c.hunks = makeHunks(diffLines(c.lines()))
+		}
+		changes = append(changes, c)
+	}
+	return changes
+}
+
+// lines returns the old and new contents split into lines.
+func (c fileChange) lines() (before, after []string) {
+	if c.old != nil {
+		before = splitLines(string(c.old.Data))
+	}
+	if c.new != nil {
+		after = splitLines(string(c.new.Data))
+	}
+	return before, after
+}
+
+// writeChange prints c as a unified diff. Paths carry git's a/ and b/
+// prefixes so the output can be fed to patch -p1 or git apply.
+func writeChange(w io.Writer, c fileChange) {
+	oldName, newName := "a/"+c.path, "b/"+c.path
+	if c.old == nil {
+		oldName = "/dev/null"
+	}
+	if c.new == nil {
+		newName = "/dev/null"
+	}
+	if c.binary() {
+		fmt.Fprintf(w, "Binary files %s and %s differ\n", oldName, newName)
+		return
+	}
+	fmt.Fprintf(w, "--- %s\n+++ %s\n", oldName, newName)
+	for _, h := range c.hunks {
+		fmt.Fprintln(w, h.header())
+		for _, e := range h.edits {
+			io.WriteString(w, string(e.op)+e.line)
+			if !strings.HasSuffix(e.line, "\n") {
+				io.WriteString(w, "\n\\ No newline at end of file\n")
+			}
+		}
+	}
+}
+
+// applyChange brings the file c.path under dir from c.old to c.new while
+// keeping local edits, describing what it did on log. It returns the number
+// of hunks, or whole files, that conflicted with local edits and were left
+// alone.
+func applyChange(dir string, c fileChange, log io.Writer) (int, error) {
+	path := filepath.Join(dir, filepath.FromSlash(c.path))
+	cur, err := os.ReadFile(path)
+	missing := errors.Is(err, fs.ErrNotExist)
+	if err != nil && !missing {
+		return 0, err
+	}
+
+	switch {
+	case c.new == nil:
+		switch {
+		case missing:
+			return 0, nil
+		case bytes.Equal(cur, c.old.Data):
+			fmt.Fprintf(log, "removed %s\n", c.path)
+			return 0, os.Remove(path)
+		}
+		fmt.Fprintf(log, "conflict: %s has local changes; not removed\n", c.path)
+		return 1, nil
+	case missing && c.old != nil:
+		fmt.Fprintf(log, "skipped %s: not in the project\n", c.path)
+		return 0, nil
+	case missing:
+		fmt.Fprintf(log, "added %s\n", c.path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return 0, err
+		}
+		return 0, os.WriteFile(path, c.new.Data, c.new.Mode.Perm()|0o600)
+	case bytes.Equal(cur, c.new.Data):
+		return 0, nil // already up to date
+	case c.old == nil || c.binary():
+		if c.old == nil || !bytes.Equal(cur, c.old.Data) {
+			fmt.Fprintf(log, "conflict: %s has local changes; not replaced\n", c.path)
+			return 1, nil
+		}
+		fmt.Fprintf(log, "replaced %s\n", c.path)
+		return 0, os.WriteFile(path, c.new.Data, 0o644)
+	}
+
+	out, results := applyHunks(splitLines(string(cur)), c.hunks)
+	applied, conflicts := 0, 0
+	for k, r := range results {
+		switch r {
+		case hunkApplied:
+			applied++
+		case hunkConflict:
+			conflicts++
+			fmt.Fprintf(log, "conflict: %s %s does not match the project\n", c.path, c.hunks[k].header())
+		}
+	}
+	if applied == 0 {
+		return conflicts, nil
+	}
+	fmt.Fprintf(log, "patched %s (%d of %d hunks)\n", c.path, applied, len(c.hunks))
+	return conflicts, os.WriteFile(path, []byte(strings.Join(out, "")), 0o644)
+}
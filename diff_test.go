@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mikoto2000/spring-initializr-cli/initializr"
+)
+
+func numbered(from, to int) string {
+	var b strings.Builder
+	for i := from; i <= to; i++ {
+		b.WriteString("line " + string(rune('a'+i-1)) + "\n")
+	}
+	return b.String()
+}
+
+func textChange(path, before, after string) fileChange {
+	return compareFiles(
+		map[string]initializr.File{path: {Path: path, Data: []byte(before)}},
+		map[string]initializr.File{path: {Path: path, Data: []byte(after)}},
+		nil,
+	)[0]
+}
+
+func TestWriteChangeUnified(t *testing.T) {
+	before := numbered(1, 20)
+	after := strings.Replace(before, "line c\n", "line C\n", 1)
+	after = strings.Replace(after, "line q\n", "", 1)
+	after += "tail"
+
+	var out bytes.Buffer
+	writeChange(&out, textChange("pom.xml", before, after))
+	want := `--- a/pom.xml
++++ b/pom.xml
+@@ -1,6 +1,6 @@
+ line a
+ line b
+-line c
++line C
+ line d
+ line e
+ line f
+@@ -14,7 +14,7 @@
+ line n
+ line o
+ line p
+-line q
+ line r
+ line s
+ line t
++tail
+\ No newline at end of file
+`
+	if out.String() != want {
+		t.Fatalf("diff:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+func TestMakeHunksMergesNearbyChanges(t *testing.T) {
+	before := numbered(1, 12)
+	after := strings.Replace(strings.Replace(before, "line b\n", "B\n", 1), "line i\n", "I\n", 1)
+	c := textChange("f", before, after)
+	if len(c.hunks) != 1 || c.hunks[0].header() != "@@ -1,12 +1,12 @@" {
+		t.Fatalf("hunks = %d, first %q", len(c.hunks), c.hunks[0].header())
+	}
+}
+
+func TestApplyHunksKeepsLocalEdits(t *testing.T) {
+	before := numbered(1, 20)
+	after := strings.Replace(strings.Replace(before, "line c\n", "line C\n", 1), "line r\n", "line R\n", 1)
+	c := textChange("f", before, after)
+
+	// Lines inserted at the top shift the second hunk; the first one's
+	// context was edited locally.
+	local := "header\nheader\n" + strings.Replace(before, "line b\n", "line b (edited)\n", 1)
+	out, results := applyHunks(splitLines(local), c.hunks)
+	if len(results) != 2 || results[0] != hunkConflict || results[1] != hunkApplied {
+		t.Fatalf("results = %v", results)
+	}
+	got := strings.Join(out, "")
+	if !strings.Contains(got, "line b (edited)\nline c\n") || !strings.Contains(got, "line R\n") {
+		t.Fatalf("patched:\n%s", got)
+	}
+
+	// Applying again finds the changes already there.
+	_, results = applyHunks(splitLines(after), c.hunks)
+	if results[0] != hunkPresent || results[1] != hunkPresent {
+		t.Fatalf("results on patched file = %v", results)
+	}
+}
+
+func TestApplyChangeFiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, body string) {
+		os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644)
+	}
+	file := func(name, body string) *initializr.File {
+		return &initializr.File{Path: name, Mode: 0o755, Data: []byte(body)}
+	}
+	write("removed", "old\n")
+	write("kept", "edited\n")
+	write("bin", "\x00edited")
+
+	var log bytes.Buffer
+	conflicts := 0
+	for _, c := range []fileChange{
+		{path: "added", new: file("added", "new\n")},
+		{path: "removed", old: file("removed", "old\n")},
+		{path: "kept", old: file("kept", "old\n")},
+		{path: "bin", old: file("bin", "\x00old"), new: file("bin", "\x00new")},
+		{path: "absent", old: file("absent", "a\n"), new: file("absent", "b\n")},
+	} {
+		n, err := applyChange(dir, c, &log)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conflicts += n
+	}
+	if conflicts != 2 {
+		t.Fatalf("conflicts = %d; log:\n%s", conflicts, log.String())
+	}
+	if fi, err := os.Stat(filepath.Join(dir, "added")); err != nil || fi.Mode().Perm()&0o100 == 0 {
+		t.Fatalf("added file: %v %v", fi, err)
+	}
+	for _, name := range []string{"removed", "absent"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Fatalf("%s: err = %v", name, err)
+		}
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "kept")); string(b) != "edited\n" {
+		t.Fatalf("kept = %q", b)
+	}
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mikoto2000/spring-initializr-cli/initializr"
+)
+
+// downloadResult describes what a finished download produced.
+type downloadResult struct {
+	path      string // saved zip file or extraction directory
+	files     int    // regular files in the archive (or extracted)
+	extracted bool
+
+	// mirrored lists the files rewritten for --maven-mirror and
+	// --gradle-mirror.
+	mirrored []string
+
+	// overlaid counts the overlay files written and overlaySkipped lists
+	// those the project already had, under --overlay-conflict skip.
+	overlaid       int
+	overlaySkipped []string
+
+	// gitInit reports that --git-init created a repository; gitEnclosing is
+	// the root of the work tree that already held the project instead.
+	gitInit      bool
+	gitEnclosing string
+}
+
+// newClient returns an Initializr client for o.baseURL honoring o.timeout
+// and the shared transport.
+func newClient(o options) *initializr.Client {
+	c := initializr.NewClient(o.baseURL)
+	c.HTTPClient = &http.Client{Timeout: time.Duration(o.timeout) * time.Second, Transport: o.httpTransport}
+	return c
+}
+
+// download fetches the starter archive described by o and either saves it to
+// o.output or extracts it into o.BaseDir. progress may be nil. Cancelling ctx
+// aborts the transfer and removes partial output and temporary files.
+func download(ctx context.Context, o options, progress progressFunc) (downloadResult, error) {
+	p, err := resolveProject(ctx, o)
+	if err != nil {
+		return downloadResult{}, err
+	}
+	return downloadFrom(ctx, o, p, progress)
+}
+
+// downloadWith is download with the server's metadata m already at hand;
+// nil m fetches it.
+func downloadWith(ctx context.Context, o options, m *initializr.Metadata, progress progressFunc) (downloadResult, error) {
+	if m == nil {
+		return download(ctx, o, progress)
+	}
+	p, err := projectFor(o, m)
+	if err != nil {
+		return downloadResult{}, err
+	}
+	return downloadFrom(ctx, o, p, progress)
+}
+
+// downloadFrom is download with the project already resolved.
+func downloadFrom(ctx context.Context, o options, p resolvedProject, progress progressFunc) (downloadResult, error) {
+	arc, err := newClient(o).Download(ctx, p.url)
+	if err != nil {
+		return downloadResult{}, err
+	}
+	defer arc.Body.Close()
+
+	body := io.Reader(arc.Body)
+	if progress != nil {
+		body = &progressReader{r: arc.Body, total: arc.Size, fn: progress}
+	}
+
+	if p.buildFile != "" {
+		return saveBuildFile(ctx, o, p, body)
+	}
+
+	if o.extract {
+		// Download to temp file, then unzip into BaseDir
+		tmpf, err := os.CreateTemp("", "spring-initializr-*.zip")
+		if err != nil {
+			return downloadResult{}, err
+		}
+		tmp := tmpf.Name()
+		defer os.Remove(tmp)
+		if _, err := io.Copy(tmpf, body); err != nil {
+			tmpf.Close()
+			return downloadResult{}, err
+		}
+		tmpf.Close()
+
+		names, err := initializr.ListFiles(tmp, o.BaseDir)
+		if err != nil {
+			return downloadResult{}, err
+		}
+		plan, err := overlaysFor(o, p, names)
+		if err != nil {
+			return downloadResult{}, err
+		}
+		n, err := initializr.Extract(ctx, tmp, o.BaseDir)
+		if err != nil {
+			return downloadResult{}, err
+		}
+		return finishProject(ctx, o, p, plan, downloadResult{path: o.BaseDir, files: n, extracted: true})
+	}
+
+	// Save zip to file
+	if err := saveToFile(ctx, body, o.output); err != nil {
+		return downloadResult{}, err
+	}
+	n, err := initializr.CountFiles(o.output)
+	if err != nil {
+		return downloadResult{}, err
+	}
+	return downloadResult{path: o.output, files: n}, nil
+}
+
+// saveBuildFile stores the lone build file of a build type: in o.BaseDir with
+// --extract, else at o.output when --output was given, else under the file's
+// own name (build.gradle, pom.xml) rather than the default .zip name.
+func saveBuildFile(ctx context.Context, o options, p resolvedProject, body io.Reader) (downloadResult, error) {
+	dst := p.buildFile
+	switch {
+	case o.extract:
+		dst = filepath.Join(o.BaseDir, p.buildFile)
+	case o.outputSet:
+		dst = o.output
+	}
+	var plan overlayPlan
+	if o.extract {
+		var err error
+		if plan, err = overlaysFor(o, p, []string{p.buildFile}); err != nil {
+			return downloadResult{}, err
+		}
+	}
+	if err := saveToFile(ctx, body, dst); err != nil {
+		return downloadResult{}, err
+	}
+	if !o.extract {
+		return downloadResult{path: dst, files: 1}, nil
+	}
+	return finishProject(ctx, o, p, plan, downloadResult{path: o.BaseDir, files: 1, extracted: true})
+}
+
+// overlaysFor plans the overlays of o for the project p about to be
+// written to o.BaseDir with the files generated. It fails on conflicts
+// under --overlay-conflict fail, before anything is written.
+func overlaysFor(o options, p resolvedProject, generated []string) (overlayPlan, error) {
+	if len(o.overlay.all()) == 0 {
+		return overlayPlan{}, nil
+	}
+	return planOverlays(o.BaseDir, o.overlay, effectiveRequest(o.ProjectRequest, p.meta), generated)
+}
+
+// finishProject post-processes the project just written to o.BaseDir:
+// mirrors, the overlays of plan, the descriptor and git, in that order.
+func finishProject(ctx context.Context, o options, p resolvedProject, plan overlayPlan, res downloadResult) (downloadResult, error) {
+	var err error
+	if o.mirrors.enabled() {
+		if res.mirrored, err = applyMirrors(o.BaseDir, o.mirrors); err != nil {
+			return res, err
+		}
+	}
+	if len(plan.files) > 0 {
+		ov, err := applyOverlays(o.BaseDir, o.overlay, plan)
+		if err != nil {
+			return res, err
+		}
+		res.overlaid, res.overlaySkipped = ov.written, ov.skipped
+	}
+	if o.writeDescriptor {
+		if err := writeDescriptor(o.BaseDir, o, p); err != nil {
+			return res, err
+		}
+	}
+	if o.git.init {
+		if res.gitEnclosing, err = initRepository(ctx, o.BaseDir, o.git); err != nil {
+			return res, err
+		}
+		res.gitInit = res.gitEnclosing == ""
+	}
+	return res, nil
+}
+
+// generateFiles generates o from p in memory, with o.mirrors applied.
+func generateFiles(ctx context.Context, o options, p resolvedProject) (map[string]initializr.File, error) {
+	if o.verbose {
+		fmt.Fprintln(os.Stderr, "Generating:", redactURL(p.url))
+	}
+	arc, err := newClient(o).Download(ctx, p.url)
+	if err != nil {
+		return nil, err
+	}
+	var files map[string]initializr.File
+	if p.buildFile != "" {
+		defer arc.Body.Close()
+		b, err := io.ReadAll(arc.Body)
+		if err != nil {
+			return nil, err
+		}
+		files = map[string]initializr.File{p.buildFile: {Path: p.buildFile, Mode: 0o644, Data: b}}
+	} else {
+		files, err = arc.ReadFiles(ctx, o.BaseDir)
+	}
+	if err != nil || !o.mirrors.enabled() {
+		return files, err
+	}
+	// Match projects that were extracted with the same mirrors.
+	for _, name := range mirroredFiles {
+		if f, ok := files[name]; ok {
+			f.Data = o.mirrors.rewrite(name, f.Data)
+			files[name] = f
+		}
+	}
+	return files, nil
+}
+
+// summary renders a one-line description such as "Saved: demo.zip (23 files)".
+func (r downloadResult) summary() string {
+	verb := "Saved:"
+	if r.extracted {
+		verb = "Extracted into:"
+	}
+	s := fmt.Sprintf("%s %s (%d files)", verb, r.path, r.files)
+	if len(r.mirrored) > 0 {
+		s += fmt.Sprintf(", mirrors set in %s", strings.Join(r.mirrored, ", "))
+	}
+	if r.overlaid > 0 || len(r.overlaySkipped) > 0 {
+		s += fmt.Sprintf(", %d overlay files", r.overlaid)
+		if len(r.overlaySkipped) > 0 {
+			s += fmt.Sprintf(" (%d skipped)", len(r.overlaySkipped))
+		}
+	}
+	if r.gitInit {
+		s += ", git repository initialized"
+	}
+	return s
+}
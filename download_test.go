@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mikoto2000/spring-initializr-cli/initializr"
+	"github.com/mikoto2000/spring-initializr-cli/initializrtest"
+)
+
+func mockOptions(t *testing.T, baseURL string) options {
+	t.Chdir(t.TempDir())
+	return options{
+		baseURL: baseURL,
+		target:  "zip",
+		output:  "demo.zip",
+		timeout: 5,
+		ProjectRequest: initializr.ProjectRequest{
+			Type:        "maven-project",
+			Language:    "java",
+			GroupID:     "com.example",
+			ArtifactID:  "demo",
+			Name:        "demo",
+			PackageName: "com.example.demo",
+			Packaging:   "jar",
+			BaseDir:     "demo",
+		},
+	}
+}
+
+func TestDownloadSavesZip(t *testing.T) {
+	srv := initializrtest.NewServer()
+	defer srv.Close()
+	o := mockOptions(t, srv.URL)
+	o.Dependencies = []string{"web"}
+	res, err := download(context.Background(), o, nil)
+	if err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if res.path != o.output || res.extracted || res.files == 0 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+}
+
+func TestDownloadSavesBuildFile(t *testing.T) {
+	srv := initializrtest.NewServer()
+	defer srv.Close()
+	o := mockOptions(t, srv.URL)
+	o.Type = "gradle-build"
+	o.Dependencies = []string{"web"}
+	res, err := download(context.Background(), o, nil)
+	if err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	// The default demo.zip name does not fit a lone build file.
+	b, err := os.ReadFile("build.gradle")
+	if res.path != "build.gradle" || err != nil || !strings.Contains(string(b), "spring-boot-starter-web") {
+		t.Fatalf("result %+v, build.gradle %q, %v", res, b, err)
+	}
+
+	// An explicit --output is honored, even when it is the default name.
+	o.outputSet = true
+	if res, err = download(context.Background(), o, nil); err != nil || res.path != "demo.zip" {
+		t.Fatalf("result %+v, %v", res, err)
+	}
+	if b, err := os.ReadFile("demo.zip"); err != nil || !strings.Contains(string(b), "spring-boot-starter-web") {
+		t.Fatalf("demo.zip = %q, %v", b, err)
+	}
+
+	o.Type = "maven-build"
+	o.extract = true
+	if res, err = download(context.Background(), o, nil); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if b, err := os.ReadFile("demo/pom.xml"); res.path != "demo" || res.files != 1 || err != nil || !strings.Contains(string(b), "<artifactId>demo</artifactId>") {
+		t.Fatalf("result %+v, pom.xml %q, %v", res, b, err)
+	}
+}
+
+func TestDownloadExtractsWithoutNesting(t *testing.T) {
+	srv := initializrtest.NewServer()
+	defer srv.Close()
+	o := mockOptions(t, srv.URL)
+	o.extract = true
+	var last int64
+	res, err := download(context.Background(), o, func(received, total int64) { last = received })
+	if err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if !res.extracted || res.files == 0 || last == 0 {
+		t.Fatalf("unexpected result: %+v (progress %d)", res, last)
+	}
+	if _, err := os.Stat(filepath.Join(o.BaseDir, "pom.xml")); err != nil {
+		t.Fatalf("pom.xml not at project root: %v", err)
+	}
+}
+
+func TestDownloadReportsServerError(t *testing.T) {
+	srv := initializrtest.NewServer()
+	defer srv.Close()
+	o := mockOptions(t, srv.URL)
+	o.Dependencies = []string{"no-such-dep"}
+	_, err := download(context.Background(), o, nil)
+	if err == nil || !strings.Contains(err.Error(), "Unknown dependency 'no-such-dep'") {
+		t.Fatalf("err = %v; want unknown dependency", err)
+	}
+	if _, statErr := os.Stat(o.output); !os.IsNotExist(statErr) {
+		t.Fatalf("output written despite error")
+	}
+}
+
+// stallingServer sends the first chunk of a zip and then blocks until the
+// client goes away.
+func stallingServer(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "100000")
+		w.Write(make([]byte, 1024))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestDownloadCancelledLeavesNoFiles(t *testing.T) {
+	for _, extract := range []bool{false, true} {
+		o := mockOptions(t, stallingServer(t).URL)
+		o.extract = extract
+		tmp := t.TempDir()
+		t.Setenv("TMPDIR", tmp)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		_, err := download(ctx, o, func(received, total int64) { cancel() })
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("extract=%v: err = %v; want context.Canceled", extract, err)
+		}
+		for _, dir := range []string{".", tmp} {
+			if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+				t.Fatalf("extract=%v: %s not cleaned up: %v", extract, dir, entries)
+			}
+		}
+	}
+}
+
+func TestDownloadFollowsAdvertisedLinkUnderSubPath(t *testing.T) {
+	mock := initializrtest.NewHandler()
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/initializr/":
+			w.Header().Set("Content-Type", "application/vnd.initializr.v2.3+json")
+			w.Write([]byte(`{
+				"_links": {"maven-project": {"href": "` + srv.URL + `/initializr/generate/project.zip?type=maven-project{&dependencies,artifactId}", "templated": true}},
+				"type": {"default": "maven-project", "values": [{"id": "maven-project", "action": "/generate/project.zip"}]}
+			}`))
+		case "/initializr/generate/project.zip":
+			r.URL.Path = "/starter.zip"
+			mock.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	o := mockOptions(t, srv.URL+"/initializr")
+	p, err := resolveProject(context.Background(), o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(p.url, srv.URL+"/initializr/generate/project.zip?") || p.meta == nil {
+		t.Fatalf("resolveProject = %+v", p)
+	}
+	res, err := download(context.Background(), o, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.files == 0 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+}
+
+func TestDownloadFallsBackWithoutMetadata(t *testing.T) {
+	mock := initializrtest.NewHandler()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/starter.zip" {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		mock.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	o := mockOptions(t, srv.URL)
+	p, err := resolveProject(context.Background(), o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, _ := buildURL(o); p.url != want || p.meta != nil {
+		t.Fatalf("resolveProject = %+v; want offline URL %s", p, want)
+	}
+	if _, err := download(context.Background(), o, nil); err != nil {
+		t.Fatal(err)
+	}
+}
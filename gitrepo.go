@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/mail"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// gitConfig describes the repository --git-init creates in an extracted
+// project.
+type gitConfig struct {
+	init    bool
+	branch  string // initial branch; empty keeps git's init.defaultBranch
+	commit  bool
+	message string // empty means defaultGitMessage
+	author  string // "Name <email>"; empty uses git's configured identity
+	remote  string // URL added as origin
+}
+
+const defaultGitMessage = "Initial commit"
+
+// registerGitFlags adds the --git-* flags to fs.
+func registerGitFlags(fs *flag.FlagSet, g *gitConfig) {
+	fs.BoolVar(&g.init, "git-init", false, "With --extract, initialize a git repository in the project directory")
+	fs.StringVar(&g.branch, "git-branch", "", "Initial branch for --git-init (default: git's init.defaultBranch)")
+	fs.BoolVar(&g.commit, "git-commit", false, "With --git-init, commit the generated files")
+	fs.StringVar(&g.message, "git-message", "", "Message of the --git-commit commit (default: \""+defaultGitMessage+"\")")
+	fs.StringVar(&g.author, "git-author", "", "Author and committer of the --git-commit commit as \"Name <email>\" (default: git's user.name and user.email)")
+	fs.StringVar(&g.remote, "git-remote", "", "With --git-init, add this URL as the origin remote")
+}
+
+// check reports a misuse of the --git-* flags before anything is generated.
+func (g gitConfig) check(extract bool) error {
+	if !g.init {
+		if g.branch != "" || g.commit || g.remote != "" || g.message != "" || g.author != "" {
+			return errors.New("--git-branch, --git-commit, --git-message, --git-author and --git-remote require --git-init")
+		}
+		return nil
+	}
+	if !extract {
+		return errors.New("--git-init requires --extract")
+	}
+	if !g.commit && (g.message != "" || g.author != "") {
+		return errors.New("--git-message and --git-author require --git-commit")
+	}
+	if g.author != "" {
+		if _, err := mail.ParseAddress(g.author); err != nil {
+			return fmt.Errorf("invalid --git-author %q: want \"Name <email>\"", g.author)
+		}
+	}
+	return nil
+}
+
+// signature is the --git-author identity, or nil to use the configured one.
+func (g gitConfig) signature() (*object.Signature, error) {
+	if g.author == "" {
+		return nil, nil
+	}
+	a, err := mail.ParseAddress(g.author)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --git-author %q", g.author)
+	}
+	return &object.Signature{Name: a.Name, Email: a.Address, When: time.Now()}, nil
+}
+
+func (g gitConfig) commitMessage() string {
+	if g.message == "" {
+		return defaultGitMessage
+	}
+	return g.message
+}
+
+// gitRun runs git in dir and returns its trimmed standard output. Failures
+// carry git's own message.
+func gitRun(ctx context.Context, dir string, env []string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// initRepository sets up the repository described by g in dir. When dir is
+// already inside a work tree it leaves everything alone and returns that
+// tree's root. The git command is used when it is in PATH, so the user's
+// configuration and hooks apply; otherwise the repository is written with
+// go-git.
+func initRepository(ctx context.Context, dir string, g gitConfig) (enclosing string, err error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return initRepositoryBuiltin(dir, g)
+	}
+	if top, err := gitRun(ctx, dir, nil, "rev-parse", "--show-toplevel"); err == nil {
+		return top, nil
+	}
+	if _, err := gitRun(ctx, dir, nil, "init", "--quiet"); err != nil {
+		return "", err
+	}
+	if g.branch != "" {
+		// symbolic-ref works with every git version, unlike init --initial-branch.
+		if _, err := gitRun(ctx, dir, nil, "symbolic-ref", "HEAD", "refs/heads/"+g.branch); err != nil {
+			return "", err
+		}
+	}
+	if g.commit {
+		var env []string
+		sig, err := g.signature()
+		if err != nil {
+			return "", err
+		}
+		if sig != nil {
+			env = []string{
+				"GIT_AUTHOR_NAME=" + sig.Name, "GIT_AUTHOR_EMAIL=" + sig.Email,
+				"GIT_COMMITTER_NAME=" + sig.Name, "GIT_COMMITTER_EMAIL=" + sig.Email,
+			}
+		}
+		if _, err := gitRun(ctx, dir, nil, "add", "--all"); err != nil {
+			return "", err
+		}
+		if _, err := gitRun(ctx, dir, env, "commit", "--quiet", "--message", g.commitMessage()); err != nil {
+			return "", err
+		}
+	}
+	if g.remote != "" {
+		if _, err := gitRun(ctx, dir, nil, "remote", "add", "origin", g.remote); err != nil {
+			return "", err
+		}
+	}
+	return "", nil
+}
+
+// initRepositoryBuiltin is initRepository without the git command. Like
+// git, it takes the initial branch and the identity from the global
+// configuration when g leaves them out.
+func initRepositoryBuiltin(dir string, g gitConfig) (enclosing string, err error) {
+	if r, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true}); err == nil {
+		if wt, err := r.Worktree(); err == nil {
+			return filepath.Abs(wt.Filesystem.Root())
+		}
+	}
+	branch := g.branch
+	if branch == "" {
+		if c, err := gitconfig.LoadConfig(gitconfig.GlobalScope); err == nil {
+			branch = c.Init.DefaultBranch
+		}
+	}
+	var opts git.PlainInitOptions
+	if branch != "" {
+		opts.DefaultBranch = plumbing.NewBranchReferenceName(branch)
+	}
+	r, err := git.PlainInitWithOptions(dir, &opts)
+	if err != nil {
+		return "", fmt.Errorf("git init: %w", err)
+	}
+	if g.commit {
+		sig, err := g.signature()
+		if err != nil {
+			return "", err
+		}
+		wt, err := r.Worktree()
+		if err != nil {
+			return "", err
+		}
+		if err := wt.AddWithOptions(&git.AddOptions{All: true}); err != nil {
+			return "", fmt.Errorf("git add: %w", err)
+		}
+		if _, err := wt.Commit(g.commitMessage(), &git.CommitOptions{Author: sig, Committer: sig}); errors.Is(err, git.ErrMissingAuthor) {
+			return "", errors.New("git commit: no user.name and user.email configured; set them or pass --git-author")
+		} else if err != nil {
+			return "", fmt.Errorf("git commit: %w", err)
+		}
+	}
+	if g.remote != "" {
+		if _, err := r.CreateRemote(&gitconfig.RemoteConfig{Name: "origin", URLs: []string{g.remote}}); err != nil {
+			return "", fmt.Errorf("git remote: %w", err)
+		}
+	}
+	return "", nil
+}
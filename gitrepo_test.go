@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+
+	"github.com/mikoto2000/spring-initializr-cli/initializrtest"
+)
+
+// isolateGit skips the test without git and hides the user's git config.
+func isolateGit(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(t.TempDir(), "gitconfig"))
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+}
+
+func gitOutput(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := gitRun(context.Background(), dir, nil, args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestGitInitCommitsProject(t *testing.T) {
+	isolateGit(t)
+	srv := initializrtest.NewServer()
+	defer srv.Close()
+	o := mockOptions(t, srv.URL)
+	o.extract = true
+	o.git = gitConfig{init: true, branch: "trunk", commit: true, author: "Jane Doe <jane@example.com>", remote: "git@example.com:acme/demo.git"}
+
+	res, err := download(context.Background(), o, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.gitInit || !strings.HasSuffix(res.summary(), ", git repository initialized") {
+		t.Fatalf("result = %+v", res)
+	}
+	if got := gitOutput(t, "demo", "log", "--format=%an <%ae>|%cn|%s|%D"); got != "Jane Doe <jane@example.com>|Jane Doe|Initial commit|HEAD -> trunk" {
+		t.Fatalf("log = %q", got)
+	}
+	if got := gitOutput(t, "demo", "status", "--porcelain"); got != "" {
+		t.Fatalf("uncommitted files:\n%s", got)
+	}
+	if got := gitOutput(t, "demo", "remote", "get-url", "origin"); got != o.git.remote {
+		t.Fatalf("origin = %q", got)
+	}
+}
+
+func TestGitInitWithoutGitCommand(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	srv := initializrtest.NewServer()
+	defer srv.Close()
+	o := mockOptions(t, srv.URL)
+	o.extract = true
+	o.git = gitConfig{init: true, branch: "trunk", commit: true, message: "Generated", author: "Jane Doe <jane@example.com>", remote: "git@example.com:acme/demo.git"}
+
+	res, err := download(context.Background(), o, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.gitInit {
+		t.Fatalf("result = %+v", res)
+	}
+	r, err := git.PlainOpen("demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := r.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head.Name() != "refs/heads/trunk" {
+		t.Fatalf("HEAD = %s", head.Name())
+	}
+	c, err := r.CommitObject(head.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Author.String() != "Jane Doe <jane@example.com>" || c.Committer.Name != "Jane Doe" || c.Message != "Generated" {
+		t.Fatalf("commit = %s / %s / %q", c.Author, c.Committer, c.Message)
+	}
+	if _, err := c.File("pom.xml"); err != nil {
+		t.Error(err)
+	}
+	if _, err := c.File("HELP.md"); err == nil {
+		t.Error("ignored HELP.md was committed")
+	}
+	wt, err := r.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st, err := wt.Status(); err != nil || !st.IsClean() {
+		t.Fatalf("status = %v, %v", st, err)
+	}
+	remote, err := r.Remote("origin")
+	if err != nil || remote.Config().URLs[0] != o.git.remote {
+		t.Fatalf("origin = %v, %v", remote, err)
+	}
+
+	// A second run finds the repository it created.
+	if enclosing, err := initRepository(context.Background(), filepath.Join("demo", "src"), o.git); err != nil || !strings.HasSuffix(enclosing, "demo") {
+		t.Fatalf("enclosing = %q, %v", enclosing, err)
+	}
+}
+
+func TestGitInitSkipsInsideRepository(t *testing.T) {
+	isolateGit(t)
+	srv := initializrtest.NewServer()
+	defer srv.Close()
+	o := mockOptions(t, srv.URL)
+	gitOutput(t, ".", "init", "--quiet")
+	o.extract = true
+	o.git = gitConfig{init: true, commit: true}
+
+	res, err := download(context.Background(), o, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.gitInit || res.gitEnclosing == "" {
+		t.Fatalf("result = %+v", res)
+	}
+	if top := gitOutput(t, "demo", "rev-parse", "--show-toplevel"); top != res.gitEnclosing {
+		t.Fatalf("demo has its own repository at %s", top)
+	}
+}
+
+func TestGitFlagsCheck(t *testing.T) {
+	cases := []struct {
+		g       gitConfig
+		extract bool
+		want    string
+	}{
+		{gitConfig{commit: true}, true, "require --git-init"},
+		{gitConfig{author: "Jane Doe <jane@example.com>"}, true, "require --git-init"},
+		{gitConfig{init: true, message: "Generated"}, true, "require --git-commit"},
+		{gitConfig{init: true, author: "Jane Doe <jane@example.com>"}, true, "require --git-commit"},
+		{gitConfig{init: true}, false, "requires --extract"},
+		{gitConfig{init: true, commit: true, author: "nobody"}, true, "invalid --git-author"},
+	}
+	for _, c := range cases {
+		if err := c.g.check(c.extract); err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%+v: err = %v, want %q", c.g, err, c.want)
+		}
+	}
+	if err := (gitConfig{}).check(false); err != nil {
+		t.Error(err)
+	}
+}
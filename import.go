@@ -0,0 +1,407 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mikoto2000/spring-initializr-cli/initializr"
+)
+
+// buildDependency is a dependency declared in a build file.
+type buildDependency struct {
+	groupID, artifactID string
+}
+
+func (d buildDependency) String() string { return d.groupID + ":" + d.artifactID }
+
+// implicitDependencies are added by Initializr to every project and have no
+// dependency ID of their own.
+var implicitDependencies = map[string]bool{
+	"org.springframework.boot:spring-boot-starter":        true,
+	"org.springframework.boot:spring-boot-starter-test":   true,
+	"org.springframework.boot:spring-boot-starter-tomcat": true,
+}
+
+// importedProject is what could be read back from an existing project.
+type importedProject struct {
+	request initializr.ProjectRequest
+	deps    []buildDependency
+}
+
+// importProject reads the project settings from the Maven or Gradle build in
+// dir.
+func importProject(dir string) (importedProject, error) {
+	var p importedProject
+	var err error
+	switch {
+	case exists(filepath.Join(dir, "pom.xml")):
+		p, err = readMavenProject(dir)
+	case exists(filepath.Join(dir, "build.gradle")), exists(filepath.Join(dir, "build.gradle.kts")):
+		p, err = readGradleProject(dir)
+	default:
+		return p, fmt.Errorf("%s has no pom.xml, build.gradle or build.gradle.kts", dir)
+	}
+	if err != nil {
+		return p, err
+	}
+	if p.request.Name == "" {
+		p.request.Name = p.request.ArtifactID
+	}
+	p.request.PackageName = mainPackage(dir, p.request.Language)
+	p.request.ConfigurationFileFormat = configurationFileFormat(dir)
+	return p, nil
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// pomFile is the part of a Maven POM that Initializr generates.
+type pomFile struct {
+	Parent      pomArtifact `xml:"parent"`
+	GroupID     string      `xml:"groupId"`
+	ArtifactID  string      `xml:"artifactId"`
+	Name        string      `xml:"name"`
+	Description string      `xml:"description"`
+	Packaging   string      `xml:"packaging"`
+	Properties  struct {
+		Entries []struct {
+			XMLName xml.Name
+			Value   string `xml:",chardata"`
+		} `xml:",any"`
+	} `xml:"properties"`
+	Dependencies []pomArtifact `xml:"dependencies>dependency"`
+	Managed      []pomArtifact `xml:"dependencyManagement>dependencies>dependency"`
+	Plugins      []pomArtifact `xml:"build>plugins>plugin"`
+}
+
+type pomArtifact struct {
+	GroupID    string `xml:"groupId"`
+	ArtifactID string `xml:"artifactId"`
+	Version    string `xml:"version"`
+}
+
+var pomProperty = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// readMavenProject reads dir/pom.xml.
+func readMavenProject(dir string) (importedProject, error) {
+	var p importedProject
+	b, err := os.ReadFile(filepath.Join(dir, "pom.xml"))
+	if err != nil {
+		return p, err
+	}
+	var pom pomFile
+	if err := xml.Unmarshal(b, &pom); err != nil {
+		return p, fmt.Errorf("pom.xml: %w", err)
+	}
+	props := map[string]string{}
+	for _, e := range pom.Properties.Entries {
+		props[e.XMLName.Local] = strings.TrimSpace(e.Value)
+	}
+	resolve := func(s string) string {
+		return pomProperty.ReplaceAllStringFunc(strings.TrimSpace(s), func(ref string) string {
+			if v, ok := props[ref[2:len(ref)-1]]; ok {
+				return v
+			}
+			return ref
+		})
+	}
+
+	r := &p.request
+	r.Type = "maven-project"
+	r.GroupID = resolve(pom.GroupID)
+	if r.GroupID == "" {
+		r.GroupID = resolve(pom.Parent.GroupID) // inherited
+	}
+	r.ArtifactID = resolve(pom.ArtifactID)
+	r.Name = resolve(pom.Name)
+	r.Description = resolve(pom.Description)
+	r.Packaging = resolve(pom.Packaging)
+	if r.Packaging == "" {
+		r.Packaging = "jar"
+	}
+	r.JavaVersion = props["java.version"]
+
+	if pom.Parent.GroupID == "org.springframework.boot" && pom.Parent.ArtifactID == "spring-boot-starter-parent" {
+		r.BootVersion = resolve(pom.Parent.Version)
+	}
+	for _, a := range append(pom.Managed, pom.Plugins...) {
+		if r.BootVersion == "" && a.GroupID == "org.springframework.boot" &&
+			(a.ArtifactID == "spring-boot-dependencies" || a.ArtifactID == "spring-boot-maven-plugin") {
+			r.BootVersion = resolve(a.Version)
+		}
+	}
+
+	r.Language = "java"
+	for _, a := range pom.Plugins {
+		switch a.ArtifactID {
+		case "kotlin-maven-plugin":
+			r.Language = "kotlin"
+		case "gmavenplus-plugin":
+			r.Language = "groovy"
+		}
+	}
+	for _, d := range pom.Dependencies {
+		p.deps = append(p.deps, buildDependency{resolve(d.GroupID), resolve(d.ArtifactID)})
+	}
+	return p, nil
+}
+
+var (
+	gradleGroup        = regexp.MustCompile(`(?m)^\s*group\s*=\s*['"]([^'"]+)['"]`)
+	gradleDescription  = regexp.MustCompile(`(?m)^\s*description\s*=\s*['"]([^'"]*)['"]`)
+	gradleRootName     = regexp.MustCompile(`rootProject\.name\s*=\s*['"]([^'"]+)['"]`)
+	gradleBootPlugin   = regexp.MustCompile(`id\s*\(?\s*['"]org\.springframework\.boot['"]\s*\)?\s*version\s*\(?\s*['"]([^'"]+)['"]`)
+	gradleToolchain    = regexp.MustCompile(`JavaLanguageVersion\.of\(\s*['"]?(\d+)`)
+	gradleSourceCompat = regexp.MustCompile(`sourceCompatibility\s*=\s*(?:JavaVersion\.VERSION_)?['"]?([\d._]+)`)
+	gradleKotlin       = regexp.MustCompile(`kotlin\s*\(\s*"jvm"\s*\)|org\.jetbrains\.kotlin\.jvm`)
+	gradleGroovy       = regexp.MustCompile(`(?m)id\s*\(?\s*['"]groovy['"]|^\s*groovy\s*$`)
+	gradleWar          = regexp.MustCompile(`(?m)id\s*\(?\s*['"]war['"]|^\s*war\s*$|providedRuntime\s*\(?\s*['"]org\.springframework\.boot:spring-boot-starter-tomcat['"]`)
+	gradleDependency   = regexp.MustCompile(`\b(?:implementation|api|compileOnly|runtimeOnly|developmentOnly|annotationProcessor|testImplementation|testRuntimeOnly|providedRuntime|providedCompile)\s*\(?\s*['"]([\w.\-]+):([\w.\-]+)(?::[^'"]*)?['"]`)
+)
+
+// readGradleProject reads dir/build.gradle(.kts) and settings.gradle(.kts).
+// Gradle builds are scripts, so only the forms Initializr generates are
+// recognized.
+func readGradleProject(dir string) (importedProject, error) {
+	var p importedProject
+	r := &p.request
+	name, settings := "build.gradle", "settings.gradle"
+	r.Type = "gradle-project"
+	if exists(filepath.Join(dir, "build.gradle.kts")) {
+		name, settings = "build.gradle.kts", "settings.gradle.kts"
+		r.Type = "gradle-project-kotlin"
+	}
+	b, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return p, err
+	}
+	build := string(b)
+	first := func(re *regexp.Regexp, s string) string {
+		if m := re.FindStringSubmatch(s); m != nil {
+			return m[1]
+		}
+		return ""
+	}
+
+	if s, err := os.ReadFile(filepath.Join(dir, settings)); err == nil {
+		r.ArtifactID = first(gradleRootName, string(s))
+	}
+	if r.ArtifactID == "" {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return p, err
+		}
+		r.ArtifactID = filepath.Base(abs)
+	}
+	r.GroupID = first(gradleGroup, build)
+	r.Description = first(gradleDescription, build)
+	r.BootVersion = first(gradleBootPlugin, build)
+	r.JavaVersion = first(gradleToolchain, build)
+	if r.JavaVersion == "" {
+		r.JavaVersion = strings.ReplaceAll(first(gradleSourceCompat, build), "_", ".")
+	}
+	switch {
+	case gradleKotlin.MatchString(build):
+		r.Language = "kotlin"
+	case gradleGroovy.MatchString(build):
+		r.Language = "groovy"
+	default:
+		r.Language = "java"
+	}
+	r.Packaging = "jar"
+	if gradleWar.MatchString(build) {
+		r.Packaging = "war"
+	}
+	for _, m := range gradleDependency.FindAllStringSubmatch(build, -1) {
+		p.deps = append(p.deps, buildDependency{m[1], m[2]})
+	}
+	return p, nil
+}
+
+var javaPackage = regexp.MustCompile(`^\s*package\s+([\w.]+)`)
+
+// mainPackage returns the package of the @SpringBootApplication class under
+// dir/src/main, or "" when there is none.
+func mainPackage(dir, language string) string {
+	var pkg string
+	root := filepath.Join(dir, "src", "main", language)
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || pkg != "" {
+			return nil
+		}
+		b, err := os.ReadFile(path)
+		if err != nil || !bytes.Contains(b, []byte("@SpringBootApplication")) {
+			return nil
+		}
+		sc := bufio.NewScanner(bytes.NewReader(b))
+		for sc.Scan() {
+			if m := javaPackage.FindStringSubmatch(sc.Text()); m != nil {
+				pkg = m[1]
+				return filepath.SkipAll
+			}
+		}
+		return nil
+	})
+	return pkg
+}
+
+// configurationFileFormat tells from src/main/resources whether the project
+// uses application.properties or application.yaml.
+func configurationFileFormat(dir string) string {
+	res := filepath.Join(dir, "src", "main", "resources")
+	switch {
+	case exists(filepath.Join(res, "application.yaml")), exists(filepath.Join(res, "application.yml")):
+		return "yaml"
+	case exists(filepath.Join(res, "application.properties")):
+		return "properties"
+	}
+	return ""
+}
+
+// mapDependencies turns build dependencies into Initializr dependency IDs
+// using coords, the server's ID to coordinate table. It also returns the
+// dependencies no ID stands for, leaving out those Initializr always adds.
+func mapDependencies(deps []buildDependency, coords map[string]initializr.Coordinate) (ids []string, unmapped []buildDependency) {
+	byCoordinate := map[string]string{}
+	keys := make([]string, 0, len(coords))
+	for id := range coords {
+		keys = append(keys, id)
+	}
+	sort.Strings(keys)
+	for _, id := range keys {
+		c := coords[id]
+		k := c.GroupID + ":" + c.ArtifactID
+		if _, ok := byCoordinate[k]; !ok {
+			byCoordinate[k] = id
+		}
+	}
+	seen := map[string]bool{}
+	for _, d := range deps {
+		id, ok := byCoordinate[d.String()]
+		switch {
+		case ok && !seen[id]:
+			seen[id] = true
+			ids = append(ids, id)
+		case !ok && !implicitDependencies[d.String()]:
+			unmapped = append(unmapped, d)
+		}
+	}
+	return ids, unmapped
+}
+
+// runImport prints the options that regenerate an existing project, as
+// command-line flags or as a preset.
+func runImport(ctx context.Context, args []string) error {
+	var o options
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	format := fs.String("format", "flags", "Output format: flags (a command line) or preset (JSON for --preset)")
+	fs.StringVar(&o.baseURL, "base-url", defaultBaseURL, "Spring Initializr base URL")
+	registerProjectFlags(fs, &o)
+	fs.IntVar(&o.timeout, "timeout", 60, "Download timeout in seconds")
+	fs.BoolVar(&o.debugHTTP, "debug-http", false, "Log HTTP requests and responses (secrets redacted) to stderr")
+	configPath := fs.String("config", defaultConfigPath(), "JSON config file (flags override its values)")
+	registerTransportFlags(fs, &o.transport)
+	registerAuthFlags(fs, &o.auth)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: spring-initializr-cli import [--format flags|preset] [options] [project-dir]\n\n")
+		fmt.Fprintf(os.Stderr, "Reads the settings of an existing project from its pom.xml or\nbuild.gradle(.kts) and prints them as flags or as a preset. Build\ndependencies are mapped to Initializr dependency IDs with the server's\n/dependencies table. Flags override what is read from the project.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() > 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	dir := "."
+	if fs.NArg() == 1 {
+		dir = fs.Arg(0)
+	}
+	if *format != "flags" && *format != "preset" {
+		return fmt.Errorf("import: unknown --format %q (want flags or preset)", *format)
+	}
+	if err := applyConfig(fs, *configPath, &o); err != nil {
+		return err
+	}
+
+	p, err := importProject(dir)
+	if err != nil {
+		return err
+	}
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	imported := options{ProjectRequest: p.request}
+	for _, sp := range sharedParams {
+		if v := *sp.value(&imported); v != "" && !set[sp.flag] {
+			*sp.value(&o) = v
+		}
+	}
+
+	if !set["dependencies"] && len(p.deps) > 0 {
+		if err := o.prepareTransport(); err != nil {
+			return err
+		}
+		coords, err := newClient(o).DependencyCoordinates(ctx, o.BootVersion)
+		if err != nil {
+			return fmt.Errorf("mapping dependencies: %w", err)
+		}
+		var unmapped []buildDependency
+		o.Dependencies, unmapped = mapDependencies(p.deps, coords)
+		for _, d := range unmapped {
+			fmt.Fprintf(os.Stderr, "note: %s has no Initializr dependency ID; left out\n", d)
+		}
+	}
+	o.target = "zip"
+	o.fillDefaults()
+
+	if *format == "flags" {
+		fmt.Println(commandLine(o))
+		return nil
+	}
+	b, err := presetJSON(o)
+	if err != nil {
+		return err
+	}
+	os.Stdout.Write(b)
+	return nil
+}
+
+// presetJSON renders o's project settings, and its server when it is not the
+// default, as a preset file. Values parseFlags derives are left out.
+func presetJSON(o options) ([]byte, error) {
+	var c fileConfig
+	if o.baseURL != defaultBaseURL {
+		c.BaseURL = redactURL(o.baseURL)
+	}
+	c.Project = o.ProjectRequest
+	if c.Project.BaseDir == c.Project.ArtifactID {
+		c.Project.BaseDir = ""
+	}
+	if c.Project.PackageName == initializr.SanitizePackage(c.Project.GroupID+"."+c.Project.ArtifactID) {
+		c.Project.PackageName = ""
+	}
+	if c.Project.ArtifactID == "" {
+		return nil, errors.New("import: no artifactId found")
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(c); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mikoto2000/spring-initializr-cli/initializr"
+	"github.com/mikoto2000/spring-initializr-cli/initializrtest"
+)
+
+// writeTree creates files under dir from a path-to-content map.
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, body := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+const kotlinPom = `<?xml version="1.0" encoding="UTF-8"?>
+<project xmlns="http://maven.apache.org/POM/4.0.0">
+	<modelVersion>4.0.0</modelVersion>
+	<parent>
+		<groupId>org.springframework.boot</groupId>
+		<artifactId>spring-boot-starter-parent</artifactId>
+		<version>3.4.9</version>
+	</parent>
+	<groupId>com.acme</groupId>
+	<artifactId>orders</artifactId>
+	<version>0.0.1-SNAPSHOT</version>
+	<packaging>war</packaging>
+	<name>Orders</name>
+	<description>Order service</description>
+	<properties>
+		<java.version>21</java.version>
+		<kotlin.version>1.9.25</kotlin.version>
+	</properties>
+	<dependencies>
+		<dependency>
+			<groupId>org.springframework.boot</groupId>
+			<artifactId>spring-boot-starter-web</artifactId>
+		</dependency>
+		<dependency>
+			<groupId>com.fasterxml.jackson.module</groupId>
+			<artifactId>jackson-module-kotlin</artifactId>
+		</dependency>
+		<dependency>
+			<groupId>org.postgresql</groupId>
+			<artifactId>postgresql</artifactId>
+			<scope>runtime</scope>
+		</dependency>
+		<dependency>
+			<groupId>org.springframework.boot</groupId>
+			<artifactId>spring-boot-starter-test</artifactId>
+			<scope>test</scope>
+		</dependency>
+	</dependencies>
+	<build>
+		<plugins>
+			<plugin>
+				<groupId>org.springframework.boot</groupId>
+				<artifactId>spring-boot-maven-plugin</artifactId>
+			</plugin>
+			<plugin>
+				<groupId>org.jetbrains.kotlin</groupId>
+				<artifactId>kotlin-maven-plugin</artifactId>
+			</plugin>
+		</plugins>
+	</build>
+</project>
+`
+
+func TestImportMavenProject(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"pom.xml": kotlinPom,
+		"src/main/kotlin/com/acme/orders/OrdersApplication.kt": "package com.acme.orders.app\n\n@SpringBootApplication\nclass OrdersApplication\n",
+		"src/main/resources/application.yml":                   "spring: {}\n",
+	})
+	p, err := importProject(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := initializr.ProjectRequest{
+		Type: "maven-project", Language: "kotlin", BootVersion: "3.4.9",
+		GroupID: "com.acme", ArtifactID: "orders", Name: "Orders", Description: "Order service",
+		PackageName: "com.acme.orders.app", Packaging: "war", JavaVersion: "21", ConfigurationFileFormat: "yaml",
+	}
+	if p.request.Values().Encode() != want.Values().Encode() {
+		t.Fatalf("request = %+v", p.request)
+	}
+
+	srv := initializrtest.NewServer()
+	defer srv.Close()
+	coords, err := initializr.NewClient(srv.URL).DependencyCoordinates(context.Background(), p.request.BootVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids, unmapped := mapDependencies(p.deps, coords)
+	if strings.Join(ids, ",") != "web,postgresql" {
+		t.Fatalf("ids = %v", ids)
+	}
+	if len(unmapped) != 1 || unmapped[0].String() != "com.fasterxml.jackson.module:jackson-module-kotlin" {
+		t.Fatalf("unmapped = %v", unmapped)
+	}
+}
+
+func TestImportMavenBootVersionFromProperty(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"pom.xml": `<project>
+	<groupId>com.acme</groupId>
+	<artifactId>billing</artifactId>
+	<properties><spring-boot.version>3.5.5</spring-boot.version></properties>
+	<dependencyManagement><dependencies><dependency>
+		<groupId>org.springframework.boot</groupId>
+		<artifactId>spring-boot-dependencies</artifactId>
+		<version>${spring-boot.version}</version>
+	</dependency></dependencies></dependencyManagement>
+</project>`})
+	p, err := importProject(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.request.BootVersion != "3.5.5" || p.request.Name != "billing" || p.request.Packaging != "jar" || p.request.Language != "java" {
+		t.Fatalf("request = %+v", p.request)
+	}
+}
+
+func TestImportGradleProjects(t *testing.T) {
+	groovy := t.TempDir()
+	writeTree(t, groovy, map[string]string{
+		"settings.gradle": "rootProject.name = 'catalog'\n",
+		"build.gradle": `plugins {
+	id 'java'
+	id 'war'
+	id 'org.springframework.boot' version '3.5.5'
+	id 'io.spring.dependency-management' version '1.1.7'
+}
+
+group = 'com.acme'
+version = '0.0.1-SNAPSHOT'
+description = 'Catalog service'
+
+java {
+	toolchain {
+		languageVersion = JavaLanguageVersion.of(17)
+	}
+}
+
+dependencies {
+	implementation 'org.springframework.boot:spring-boot-starter-data-jpa'
+	compileOnly 'org.projectlombok:lombok'
+	runtimeOnly 'com.h2database:h2'
+	annotationProcessor 'org.projectlombok:lombok'
+	providedRuntime 'org.springframework.boot:spring-boot-starter-tomcat'
+	testImplementation 'org.springframework.boot:spring-boot-starter-test'
+}
+`,
+		"src/main/resources/application.properties": "",
+	})
+	kotlin := t.TempDir()
+	writeTree(t, kotlin, map[string]string{
+		"settings.gradle.kts": "rootProject.name = \"stock\"\n",
+		"build.gradle.kts": `plugins {
+	kotlin("jvm") version "1.9.25"
+	id("org.springframework.boot") version "3.4.9"
+}
+
+group = "com.acme"
+
+java {
+	sourceCompatibility = JavaVersion.VERSION_21
+}
+
+dependencies {
+	implementation("org.springframework.boot:spring-boot-starter-webflux")
+}
+`,
+	})
+
+	cases := []struct {
+		dir  string
+		want initializr.ProjectRequest
+		deps string
+	}{
+		{groovy, initializr.ProjectRequest{
+			Type: "gradle-project", Language: "java", BootVersion: "3.5.5", GroupID: "com.acme", ArtifactID: "catalog",
+			Name: "catalog", Description: "Catalog service", Packaging: "war", JavaVersion: "17", ConfigurationFileFormat: "properties",
+		}, "data-jpa,lombok,h2"},
+		{kotlin, initializr.ProjectRequest{
+			Type: "gradle-project-kotlin", Language: "kotlin", BootVersion: "3.4.9", GroupID: "com.acme", ArtifactID: "stock",
+			Name: "stock", Packaging: "jar", JavaVersion: "21",
+		}, "webflux"},
+	}
+	srv := initializrtest.NewServer()
+	defer srv.Close()
+	coords, err := initializr.NewClient(srv.URL).DependencyCoordinates(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range cases {
+		p, err := importProject(c.dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.request.Values().Encode() != c.want.Values().Encode() {
+			t.Errorf("request = %+v", p.request)
+		}
+		if ids, unmapped := mapDependencies(p.deps, coords); strings.Join(ids, ",") != c.deps || len(unmapped) != 0 {
+			t.Errorf("%s: ids = %v, unmapped = %v", c.want.ArtifactID, ids, unmapped)
+		}
+	}
+}
+
+func TestImportPresetRoundTrip(t *testing.T) {
+	srv := initializrtest.NewServer()
+	defer srv.Close()
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"pom.xml": kotlinPom})
+
+	var err error
+	preset := captureStdout(t, func() {
+		err = runImport(context.Background(), []string{"--config", "", "--base-url", srv.URL, "--format", "preset", "--description", "Orders API", dir})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "orders.json")
+	os.WriteFile(path, []byte(preset), 0o644)
+
+	// The preset regenerates the project; flags still win over it.
+	var o options
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	extra := registerFlags(fs, &o)
+	if err := fs.Parse([]string{"--config", "", "--preset", path, "--java-version", "25"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyConfig(fs, *extra.configPath, &o); err != nil {
+		t.Fatal(err)
+	}
+	if o.baseURL != srv.URL || o.ArtifactID != "orders" || o.Language != "kotlin" || o.Description != "Orders API" ||
+		o.JavaVersion != "25" || strings.Join(o.Dependencies, ",") != "web,postgresql" {
+		t.Fatalf("options from preset: %s %+v", o.baseURL, o.ProjectRequest)
+	}
+
+	out := captureStdout(t, func() {
+		err = runImport(context.Background(), []string{"--config", "", "--base-url", srv.URL, dir})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(out, commandName+" ") || !strings.Contains(out, "--dependencies web,postgresql") || !strings.Contains(out, "--packaging war") {
+		t.Fatalf("flags = %q", out)
+	}
+}
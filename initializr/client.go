@@ -0,0 +1,128 @@
+// Package initializr is a client for the Spring Initializr HTTP API. It
+// fetches service metadata, builds starter URLs from a ProjectRequest and
+// downloads or extracts generated projects.
+package initializr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// DefaultBaseURL is the public Spring Initializr service.
+const DefaultBaseURL = "https://start.spring.io"
+
+// metadataAccept asks for the newest metadata format, falling back to JSON.
+const metadataAccept = "application/vnd.initializr.v2.3+json, application/json"
+
+// Client talks to one Initializr server. The zero value is not usable; use
+// NewClient.
+type Client struct {
+	// BaseURL is the server root, e.g. https://start.spring.io.
+	BaseURL string
+	// HTTPClient performs requests. NewClient sets http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// NewClient returns a client for the server at baseURL.
+func NewClient(baseURL string) *Client {
+	return &Client{BaseURL: baseURL, HTTPClient: http.DefaultClient}
+}
+
+// APIError is returned when the server answers with a non-2xx status.
+type APIError struct {
+	StatusCode int
+	Status     string
+	// Message is the "message" field of a Spring Boot JSON error body, if any.
+	Message string
+	// Body holds the start of the response body.
+	Body []byte
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("bad response: %s\n%s", e.Status, e.Body)
+}
+
+// base returns BaseURL without a trailing slash.
+func (c *Client) base() (string, error) {
+	b := strings.TrimRight(c.BaseURL, "/")
+	if b == "" {
+		return "", errors.New("base-url must not be empty")
+	}
+	return b, nil
+}
+
+// get performs a GET request and returns the response when its status is
+// 2xx. Otherwise the body is drained into an *APIError.
+func (c *Client) get(ctx context.Context, url, accept string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", accept)
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		apiErr := &APIError{StatusCode: resp.StatusCode, Status: resp.Status, Body: b}
+		var body struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(b, &body) == nil {
+			apiErr.Message = body.Message
+		}
+		return nil, apiErr
+	}
+	return resp, nil
+}
+
+// StarterURL returns the URL that generates the project described by r as a
+// zip archive.
+func (c *Client) StarterURL(r ProjectRequest) (string, error) {
+	base, err := c.base()
+	if err != nil {
+		return "", err
+	}
+	return base + "/starter.zip?" + r.Values().Encode(), nil
+}
+
+// Archive is a generated project being downloaded. Callers must close Body.
+type Archive struct {
+	Body io.ReadCloser
+	// Size is the archive length in bytes, or -1 when unknown.
+	Size int64
+	// Filename is the name suggested by the server, if any.
+	Filename string
+}
+
+// Generate asks the server to generate the project described by r and
+// returns the zip archive as a stream.
+func (c *Client) Generate(ctx context.Context, r ProjectRequest) (*Archive, error) {
+	u, err := c.StarterURL(r)
+	if err != nil {
+		return nil, err
+	}
+	return c.Download(ctx, u)
+}
+
+// Download fetches the archive at u, typically a URL from StarterURL or
+// ProjectURL, as a stream.
+func (c *Client) Download(ctx context.Context, u string) (*Archive, error) {
+	resp, err := c.get(ctx, u, "application/zip, application/octet-stream")
+	if err != nil {
+		return nil, err
+	}
+	a := &Archive{Body: resp.Body, Size: resp.ContentLength}
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil {
+		a.Filename = params["filename"]
+	}
+	return a, nil
+}
@@ -0,0 +1,262 @@
+package initializr_test
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mikoto2000/spring-initializr-cli/initializr"
+	"github.com/mikoto2000/spring-initializr-cli/initializrtest"
+)
+
+func TestMetadata(t *testing.T) {
+	srv := initializrtest.NewServer()
+	defer srv.Close()
+	m, err := initializr.NewClient(srv.URL).Metadata(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.BootVersion.DefaultID() != "3.5.5" || m.JavaVersion.DefaultID() != "17" {
+		t.Fatalf("defaults: boot=%q java=%q", m.BootVersion.DefaultID(), m.JavaVersion.DefaultID())
+	}
+	if ids := m.Type.IDs(); len(ids) == 0 || ids[0] != "gradle-project" && ids[0] != "maven-project" {
+		t.Fatalf("types = %v", ids)
+	}
+	if m.Type.Values[0].Action == "" {
+		t.Fatal("type action not decoded")
+	}
+	var web initializr.Dependency
+	for _, d := range m.AllDependencies() {
+		if d.ID == "web" {
+			web = d
+		}
+	}
+	if web.Name != "Spring Web" || web.Group != "Web" {
+		t.Fatalf("web dependency = %+v", web)
+	}
+}
+
+func TestDependenciesOfSkipsMetadataFetch(t *testing.T) {
+	var paths []string
+	mock := initializrtest.NewHandler()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		mock.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+	c := initializr.NewClient(srv.URL)
+	m, err := c.Metadata(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths = nil
+	if deps, err := c.DependenciesOf(context.Background(), m); err != nil || len(deps) == 0 {
+		t.Fatalf("deps = %v, %v", deps, err)
+	}
+	// Metadata without dependencies falls back to /dependencies alone.
+	if deps, err := c.DependenciesOf(context.Background(), &initializr.Metadata{}); err != nil || len(deps) == 0 {
+		t.Fatalf("deps = %v, %v", deps, err)
+	}
+	if strings.Join(paths, ",") != "/dependencies" {
+		t.Fatalf("requests = %v", paths)
+	}
+}
+
+func TestFingerprintIgnoresLinks(t *testing.T) {
+	var prints []string
+	for range 2 {
+		srv := initializrtest.NewServer()
+		m, err := initializr.NewClient(srv.URL).Metadata(context.Background())
+		srv.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		prints = append(prints, m.Fingerprint())
+		m.BootVersion.Default = "3.4.9"
+		prints = append(prints, m.Fingerprint())
+	}
+	if prints[0] != prints[2] {
+		t.Fatalf("same metadata on two hosts: %s != %s", prints[0], prints[2])
+	}
+	if prints[0] == prints[1] {
+		t.Fatal("a changed default kept the fingerprint")
+	}
+}
+
+func TestDependenciesFallsBackToLegacyList(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/dependencies" {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, `{"groups":[{"name":"Web","values":[{"id":"web","name":"Spring Web"}]}]}`)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	deps, err := initializr.NewClient(srv.URL).Dependencies(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deps) != 1 || deps[0].ID != "web" || deps[0].Group != "Web" {
+		t.Fatalf("deps = %+v", deps)
+	}
+}
+
+func TestDependencyCoordinates(t *testing.T) {
+	srv := initializrtest.NewServer()
+	defer srv.Close()
+	coords, err := initializr.NewClient(srv.URL).DependencyCoordinates(context.Background(), "3.4.9")
+	if err != nil {
+		t.Fatal(err)
+	}
+	web := coords["web"]
+	if web.GroupID != "org.springframework.boot" || web.ArtifactID != "spring-boot-starter-web" || web.Scope != "compile" {
+		t.Fatalf("web = %+v", web)
+	}
+}
+
+func TestGenerateAndExtract(t *testing.T) {
+	srv := initializrtest.NewServer()
+	defer srv.Close()
+	arc, err := initializr.NewClient(srv.URL).Generate(context.Background(), initializr.ProjectRequest{
+		ArtifactID:   "demo",
+		BaseDir:      "demo",
+		Dependencies: []string{"web"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer arc.Body.Close()
+	if arc.Filename != "demo.zip" || arc.Size <= 0 {
+		t.Fatalf("archive filename=%q size=%d", arc.Filename, arc.Size)
+	}
+
+	dir := t.TempDir()
+	zipPath := filepath.Join(dir, "demo.zip")
+	f, _ := os.Create(zipPath)
+	io.Copy(f, arc.Body)
+	f.Close()
+
+	n, err := initializr.Extract(context.Background(), zipPath, filepath.Join(dir, "demo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	total, _ := initializr.CountFiles(zipPath)
+	if n == 0 || n != total {
+		t.Fatalf("extracted %d of %d files", n, total)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "demo", "pom.xml")); err != nil {
+		t.Fatalf("top-level directory not stripped: %v", err)
+	}
+	names, err := initializr.ListFiles(zipPath, filepath.Join(dir, "demo"))
+	if err != nil || len(names) != total || !slices.Contains(names, "pom.xml") {
+		t.Fatalf("ListFiles = %v, %v", names, err)
+	}
+}
+
+func TestArchiveReadFiles(t *testing.T) {
+	srv := initializrtest.NewServer()
+	defer srv.Close()
+	arc, err := initializr.NewClient(srv.URL).Generate(context.Background(), initializr.ProjectRequest{
+		ArtifactID: "demo",
+		BaseDir:    "demo",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := arc.ReadFiles(context.Background(), "demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pom, ok := files["pom.xml"]
+	if !ok || !strings.Contains(string(pom.Data), "<artifactId>demo</artifactId>") {
+		t.Fatalf("pom.xml = %q (present %v)", pom.Data, ok)
+	}
+	if mvnw := files["mvnw"]; mvnw.Mode&0o111 == 0 {
+		t.Fatalf("mvnw mode = %v", mvnw.Mode)
+	}
+	for name := range files {
+		if strings.HasPrefix(name, "demo/") || strings.HasSuffix(name, "/") {
+			t.Fatalf("unexpected entry %q", name)
+		}
+	}
+}
+
+func TestGenerateReportsAPIError(t *testing.T) {
+	srv := initializrtest.NewServer()
+	defer srv.Close()
+	_, err := initializr.NewClient(srv.URL).Generate(context.Background(), initializr.ProjectRequest{Dependencies: []string{"nope"}})
+	var apiErr *initializr.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest || !strings.Contains(apiErr.Message, "nope") {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestGenerateHonorsContext(t *testing.T) {
+	srv := initializrtest.NewServer()
+	defer srv.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := initializr.NewClient(srv.URL).Generate(ctx, initializr.ProjectRequest{}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v; want context.Canceled", err)
+	}
+}
+
+func writeTestZip(t *testing.T, path string, names ...string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for _, name := range names {
+		w, _ := zw.Create(name)
+		io.WriteString(w, "x")
+	}
+	zw.Close()
+	f.Close()
+}
+
+func TestExtractRejectsEscapingEntriesAndRollsBack(t *testing.T) {
+	dir := t.TempDir()
+	zipPath := filepath.Join(dir, "evil.zip")
+	writeTestZip(t, zipPath, "src/ok.txt", "../evil.txt")
+
+	if _, err := initializr.Extract(context.Background(), zipPath, filepath.Join(dir, "out")); err == nil {
+		t.Fatal("expected an error for an entry outside the destination")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "evil.txt")); !os.IsNotExist(err) {
+		t.Fatal("escaping entry was written")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "out")); !os.IsNotExist(err) {
+		t.Fatal("partial extraction was not removed")
+	}
+}
+
+func TestExtractCancelledKeepsExistingFiles(t *testing.T) {
+	dir := t.TempDir()
+	zipPath := filepath.Join(dir, "demo.zip")
+	writeTestZip(t, zipPath, "a.txt", "b.txt")
+	out := filepath.Join(dir, "out")
+	os.Mkdir(out, 0o755)
+	os.WriteFile(filepath.Join(out, "keep.txt"), []byte("mine"), 0o644)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := initializr.Extract(ctx, zipPath, out); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v; want context.Canceled", err)
+	}
+	entries, _ := os.ReadDir(out)
+	if len(entries) != 1 || entries[0].Name() != "keep.txt" {
+		t.Fatalf("destination changed: %v", entries)
+	}
+}
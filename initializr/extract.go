@@ -0,0 +1,234 @@
+package initializr
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Extract unpacks the zip archive at zipPath into destDir, preserving modes
+// and structure, and returns the number of regular files written.
+//
+// When every entry lives under a single top-level directory named like
+// destDir, that component is stripped so the project does not end up in
+// destDir/destDir. Entries that would escape destDir are rejected.
+//
+// If ctx is cancelled or an entry fails, the files and directories created
+// so far are removed again and the error is returned.
+func Extract(ctx context.Context, zipPath, destDir string) (n int, err error) {
+	zr, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return 0, err
+	}
+	defer zr.Close()
+
+	var created []string
+	defer func() {
+		if err != nil {
+			for i := len(created) - 1; i >= 0; i-- {
+				os.Remove(created[i])
+			}
+			n = 0
+		}
+	}()
+	mkdirAll := func(dir string, perm os.FileMode) error {
+		var missing []string
+		for d := dir; ; d = filepath.Dir(d) {
+			if _, err := os.Lstat(d); err == nil || d == filepath.Dir(d) {
+				break
+			}
+			missing = append(missing, d)
+		}
+		for i := len(missing) - 1; i >= 0; i-- {
+			if err := os.Mkdir(missing[i], perm); err != nil && !os.IsExist(err) {
+				return err
+			}
+			created = append(created, missing[i])
+		}
+		return nil
+	}
+
+	if err := mkdirAll(destDir, 0o755); err != nil {
+		return 0, err
+	}
+
+	stripPrefix := commonRoot(zr.File, filepath.Base(destDir))
+
+	root, err := filepath.Abs(destDir)
+	if err != nil {
+		return 0, err
+	}
+	files := 0
+	for _, f := range zr.File {
+		if err := ctx.Err(); err != nil {
+			return files, err
+		}
+		name := strings.TrimPrefix(entryName(f), stripPrefix)
+		if name == "" {
+			// nothing to create (e.g., top-level dir entry when stripped)
+			continue
+		}
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if p != root && !strings.HasPrefix(p, root+string(filepath.Separator)) {
+			return files, fmt.Errorf("archive entry %q escapes %s", f.Name, destDir)
+		}
+		if f.FileInfo().IsDir() {
+			if err := mkdirAll(p, f.Mode().Perm()|0o700); err != nil {
+				return files, err
+			}
+			continue
+		}
+		if err := mkdirAll(filepath.Dir(p), 0o755); err != nil {
+			return files, err
+		}
+		if _, err := os.Lstat(p); os.IsNotExist(err) {
+			created = append(created, p)
+		}
+		if err := extractFile(ctx, f, p); err != nil {
+			return files, err
+		}
+		files++
+	}
+	return files, nil
+}
+
+// commonRoot returns "base/" when every entry lives under a single
+// top-level directory named base, and "" otherwise.
+func commonRoot(files []*zip.File, base string) string {
+	topLevels := make(map[string]struct{})
+	for _, f := range files {
+		name := entryName(f)
+		if name == "" {
+			continue
+		}
+		if i := strings.IndexByte(name, '/'); i >= 0 {
+			topLevels[name[:i]] = struct{}{}
+		} else {
+			topLevels[name] = struct{}{}
+		}
+	}
+	if len(topLevels) == 1 {
+		if _, ok := topLevels[base]; ok {
+			return base + "/"
+		}
+	}
+	return ""
+}
+
+// File is a regular file of a generated project held in memory.
+type File struct {
+	Path string // slash-separated, relative to the project root
+	Mode os.FileMode
+	Data []byte
+}
+
+// ReadFiles reads the whole archive into memory and returns its regular
+// files keyed by path. As with Extract, a single top-level directory named
+// baseDir is stripped from the paths. It closes a.Body.
+func (a *Archive) ReadFiles(ctx context.Context, baseDir string) (map[string]File, error) {
+	defer a.Body.Close()
+	b, err := io.ReadAll(contextReader{ctx, a.Body})
+	if err != nil {
+		return nil, err
+	}
+	zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return nil, err
+	}
+	strip := commonRoot(zr.File, path.Base(baseDir))
+	files := make(map[string]File)
+	for _, f := range zr.File {
+		name := strings.TrimPrefix(entryName(f), strip)
+		if name == "" || f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		files[name] = File{Path: name, Mode: f.Mode(), Data: data}
+	}
+	return files, nil
+}
+
+// entryName normalizes separators and strips leading slashes.
+func entryName(f *zip.File) string {
+	return strings.TrimLeft(strings.ReplaceAll(f.Name, "\\", "/"), "/")
+}
+
+func extractFile(ctx context.Context, f *zip.File, path string) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	w, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, f.Mode())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, contextReader{ctx, rc}); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// contextReader fails reads once ctx is done.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
+
+// CountFiles returns the number of regular files in the zip archive at
+// zipPath.
+func CountFiles(zipPath string) (int, error) {
+	zr, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return 0, err
+	}
+	defer zr.Close()
+	n := 0
+	for _, f := range zr.File {
+		if !f.FileInfo().IsDir() {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// ListFiles returns the slash-separated paths, relative to destDir, of the
+// regular files Extract would write from the zip archive at zipPath.
+func ListFiles(zipPath, destDir string) ([]string, error) {
+	zr, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	strip := commonRoot(zr.File, filepath.Base(destDir))
+	var names []string
+	for _, f := range zr.File {
+		name := strings.TrimPrefix(entryName(f), strip)
+		if name != "" && !f.FileInfo().IsDir() {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
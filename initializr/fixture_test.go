@@ -0,0 +1,250 @@
+package initializr_test
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/mikoto2000/spring-initializr-cli/initializr"
+	"github.com/mikoto2000/spring-initializr-cli/initializrtest"
+)
+
+var record = flag.String("record", "", "re-record testdata/fixtures from this Initializr base URL, e.g. https://start.spring.io")
+
+// unknownType is a request the server rejects with a JSON error body.
+var unknownType = initializr.ProjectRequest{Type: "unknown", ArtifactID: "demo"}
+
+// fixtures lists how each cassette in testdata/fixtures is recorded. The
+// committed cassettes are trimmed to a few values per section; re-recording
+// replaces them with full responses.
+var fixtures = []struct {
+	name   string
+	accept string // metadata version to ask for
+	record func(ctx context.Context, c *initializr.Client) error
+}{
+	{"v2.3", "application/vnd.initializr.v2.3+json", getPaths("/")},
+	{"v2.2", "application/vnd.initializr.v2.2+json", getPaths("/")},
+	// Servers that only speak v2.1 serve it from /metadata/client.
+	{"v2.1", "application/vnd.initializr.v2.1+json", getPaths("/metadata/client")},
+	{"dependencies", "", getPaths("/dependencies")},
+	{"errors", "", func(ctx context.Context, c *initializr.Client) error {
+		if _, err := c.Generate(ctx, unknownType); err == nil {
+			return errors.New("generating an unknown type succeeded")
+		}
+		return nil
+	}},
+}
+
+func getPaths(paths ...string) func(context.Context, *initializr.Client) error {
+	return func(ctx context.Context, c *initializr.Client) error {
+		for _, p := range paths {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+p, nil)
+			if err != nil {
+				return err
+			}
+			resp, err := c.HTTPClient.Do(req)
+			if err != nil {
+				return err
+			}
+			resp.Body.Close()
+		}
+		return nil
+	}
+}
+
+func fixturePath(name string) string {
+	return filepath.Join("testdata", "fixtures", name+".json")
+}
+
+// TestRecordFixtures re-records the cassettes when run with
+// -record https://start.spring.io (or another server).
+func TestRecordFixtures(t *testing.T) {
+	if *record == "" {
+		t.Skip("run with -record <base-url> to re-record fixtures")
+	}
+	for _, f := range fixtures {
+		rec := initializrtest.NewRecorder(*record)
+		rec.Accept = f.accept
+		c := initializr.NewClient(*record)
+		c.HTTPClient = &http.Client{Transport: rec}
+		if err := f.record(context.Background(), c); err != nil {
+			t.Fatalf("%s: %v", f.name, err)
+		}
+		if err := rec.Cassette.Save(fixturePath(f.name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// replayClient returns a client answered from the named cassette and the
+// recorder that logs which requests it made.
+func replayClient(t *testing.T, name string) (*initializr.Client, *initializrtest.Recorder) {
+	t.Helper()
+	cas, err := initializrtest.LoadCassette(fixturePath(name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cassetteClient(cas)
+}
+
+func cassetteClient(cas *initializrtest.Cassette) (*initializr.Client, *initializrtest.Recorder) {
+	log := initializrtest.NewRecorder(cas.BaseURL)
+	log.Next = initializrtest.Replay(cas)
+	c := initializr.NewClient(cas.BaseURL)
+	c.HTTPClient = &http.Client{Transport: log}
+	return c, log
+}
+
+// requested lists "path status" for every request the recorder saw.
+func requested(rec *initializrtest.Recorder) []string {
+	var out []string
+	for _, in := range rec.Cassette.Interactions {
+		out = append(out, in.Request.Path+" "+http.StatusText(in.Response.Status))
+	}
+	return out
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestMetadataFixtures(t *testing.T) {
+	cases := []struct {
+		fixture     string
+		requests    []string
+		bootDefault string
+		configFmt   string
+	}{
+		{"v2.3", []string{"/ OK"}, "3.5.5", "properties"},
+		{"v2.2", []string{"/ OK"}, "3.5.5", ""},
+		{"v2.1", []string{"/ Not Found", "/metadata/client OK"}, "3.5.5.RELEASE", ""},
+	}
+	for _, c := range cases {
+		t.Run(c.fixture, func(t *testing.T) {
+			client, rec := replayClient(t, c.fixture)
+			m, err := client.Metadata(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := requested(rec); !equal(got, c.requests) {
+				t.Fatalf("requests = %q; want %q", got, c.requests)
+			}
+			if got := m.BootVersion.DefaultID(); got != c.bootDefault {
+				t.Fatalf("boot default = %q; want %q", got, c.bootDefault)
+			}
+			if got := initializr.NormalizeBootVersion(m.BootVersion.DefaultID()); got != "3.5.5" {
+				t.Fatalf("normalized boot default = %q", got)
+			}
+			if m.JavaVersion.DefaultID() != "17" || m.Language.DefaultID() != "java" || m.Type.DefaultID() != "maven-project" {
+				t.Fatalf("defaults: java=%q language=%q type=%q", m.JavaVersion.DefaultID(), m.Language.DefaultID(), m.Type.DefaultID())
+			}
+			if got := m.ConfigurationFileFormat.DefaultID(); got != c.configFmt {
+				t.Fatalf("configurationFileFormat default = %q; want %q", got, c.configFmt)
+			}
+			if m.GroupID.Default != "com.example" || m.PackageName.Default != "com.example.demo" {
+				t.Fatalf("text defaults: %+v %+v", m.GroupID, m.PackageName)
+			}
+			deps := m.AllDependencies()
+			if len(deps) != 5 || deps[2].ID != "web" || deps[2].Group != "Web" || deps[4].VersionRange == "" {
+				t.Fatalf("dependencies = %+v", deps)
+			}
+		})
+	}
+}
+
+func TestMetadataSkipsRootWithoutMetadata(t *testing.T) {
+	v21, err := initializrtest.LoadCassette(fixturePath("v2.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A root answering with links only, as a gateway in front of an old
+	// server might, must not be taken for empty metadata.
+	cas := *v21
+	cas.Interactions = append([]initializrtest.Interaction{{
+		Request:  initializrtest.RecordedRequest{Method: "GET", Path: "/"},
+		Response: initializrtest.RecordedResponse{Status: 200, ContentType: "application/hal+json", JSON: []byte(`{"_links":{"self":{"href":"/"}}}`)},
+	}}, v21.Interactions...)
+	client, rec := cassetteClient(&cas)
+	m, err := client.Metadata(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := requested(rec); !equal(got, []string{"/ OK", "/metadata/client OK"}) {
+		t.Fatalf("requests = %q", got)
+	}
+	if len(m.Type.IDs()) != 5 {
+		t.Fatalf("types = %v", m.Type.IDs())
+	}
+}
+
+func TestDependenciesFixtures(t *testing.T) {
+	client, rec := replayClient(t, "v2.3")
+	deps, err := client.Dependencies(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deps) != 5 || deps[0].ID != "devtools" || deps[0].Group != "Developer Tools" {
+		t.Fatalf("deps from metadata = %+v", deps)
+	}
+	if got := requested(rec); !equal(got, []string{"/ OK"}) {
+		t.Fatalf("requests = %q", got)
+	}
+
+	// Without metadata, the coordinates map of /dependencies is used.
+	client, rec = replayClient(t, "dependencies")
+	deps, err = client.Dependencies(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"/ Not Found", "/metadata/client Not Found", "/dependencies OK"}
+	if got := requested(rec); !equal(got, want) {
+		t.Fatalf("requests = %q; want %q", got, want)
+	}
+	var ids []string
+	for _, d := range deps {
+		ids = append(ids, d.ID)
+	}
+	if !equal(ids, []string{"devtools", "lombok", "vaadin", "web", "webflux"}) {
+		t.Fatalf("ids = %q", ids)
+	}
+	if deps[3].Name != "web" || deps[3].Description != "org.springframework.boot:spring-boot-starter-web" {
+		t.Fatalf("web = %+v", deps[3])
+	}
+}
+
+func TestDependenciesLegacyArray(t *testing.T) {
+	client, _ := cassetteClient(&initializrtest.Cassette{Interactions: []initializrtest.Interaction{{
+		Request:  initializrtest.RecordedRequest{Method: "GET", Path: "/dependencies"},
+		Response: initializrtest.RecordedResponse{Status: 200, ContentType: "application/json", JSON: []byte(`{"dependencies":[{"id":"web","name":"Spring Web","group":"Web"}]}`)},
+	}}, BaseURL: "https://initializr.example"})
+	deps, err := client.Dependencies(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deps) != 1 || deps[0].ID != "web" || deps[0].Group != "Web" {
+		t.Fatalf("deps = %+v", deps)
+	}
+}
+
+func TestGenerateErrorFixture(t *testing.T) {
+	client, _ := replayClient(t, "errors")
+	_, err := client.Generate(context.Background(), unknownType)
+	var apiErr *initializr.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("err = %v; want *APIError", err)
+	}
+	if apiErr.StatusCode != 400 || apiErr.Message != "Unknown type 'unknown' check project metadata" {
+		t.Fatalf("APIError = %+v", apiErr)
+	}
+}
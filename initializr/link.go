@@ -0,0 +1,114 @@
+package initializr
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ProjectURL returns the URL that generates the project described by r,
+// following what the server advertises in m for r.Type: its templated HAL
+// link, else its action (e.g. /starter.zip) under BaseURL. Servers mounted
+// under a sub-path or with custom actions work this way. When m is nil or
+// does not know the type, ProjectURL falls back to StarterURL.
+//
+// For types that generate a lone build file rather than an archive, such as
+// maven-build, the URL is that of the file; see Metadata.IsBuildType.
+func (c *Client) ProjectURL(m *Metadata, r ProjectRequest) (string, error) {
+	if m == nil {
+		return c.StarterURL(r)
+	}
+	var typ *Option
+	for i := range m.Type.Values {
+		if m.Type.Values[i].ID == r.Type {
+			typ = &m.Type.Values[i]
+		}
+	}
+	if typ == nil {
+		return c.StarterURL(r)
+	}
+	base, err := c.base()
+	if err != nil {
+		return "", err
+	}
+	if l, ok := m.Links[r.Type]; ok && l.Href != "" {
+		return expandLink(base, l, r.Values())
+	}
+	if typ.Action != "" {
+		u, err := url.Parse(typ.Action)
+		if err != nil {
+			return "", fmt.Errorf("invalid action %q for type %s: %w", typ.Action, r.Type, err)
+		}
+		if !u.IsAbs() {
+			// Actions are relative to the service root, which may be a sub-path.
+			u, err = url.Parse(base + "/" + strings.TrimLeft(typ.Action, "/"))
+			if err != nil {
+				return "", err
+			}
+		}
+		inheritUser(u, base)
+		return withValues(u, r.Values()), nil
+	}
+	return c.StarterURL(r)
+}
+
+// expandLink fills a HAL link with values. The template expressions only
+// list the variables the server documents, so they are dropped and every
+// value is sent; parameters fixed in the link (such as type) are kept.
+func expandLink(base string, l Link, values url.Values) (string, error) {
+	href := l.Href
+	if l.Templated {
+		var b strings.Builder
+		for {
+			open := strings.IndexByte(href, '{')
+			if open < 0 {
+				break
+			}
+			end := strings.IndexByte(href[open:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated template in link %q", l.Href)
+			}
+			b.WriteString(href[:open])
+			href = href[open+end+1:]
+		}
+		b.WriteString(href)
+		href = b.String()
+	}
+	u, err := url.Parse(href)
+	if err != nil {
+		return "", fmt.Errorf("invalid link %q: %w", l.Href, err)
+	}
+	if !u.IsAbs() {
+		root, err := url.Parse(base + "/")
+		if err != nil {
+			return "", err
+		}
+		u = root.ResolveReference(u)
+	}
+	inheritUser(u, base)
+	return withValues(u, values), nil
+}
+
+// inheritUser gives u the user info of base when both name the same host,
+// so that credentials in BaseURL reach links the server advertises with
+// its own scheme or port too.
+func inheritUser(u *url.URL, base string) {
+	b, err := url.Parse(base)
+	if err != nil || b.User == nil || u.User != nil || !strings.EqualFold(u.Hostname(), b.Hostname()) {
+		return
+	}
+	u.User = b.User
+}
+
+// withValues returns u with values added to its query. Parameters already
+// present in u win.
+func withValues(u *url.URL, values url.Values) string {
+	q := u.Query()
+	for k, v := range values {
+		if _, ok := q[k]; !ok {
+			q[k] = v
+		}
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
@@ -0,0 +1,330 @@
+package initializr
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+)
+
+// Metadata is the service description returned by the server root
+// (application/vnd.initializr.v2.3+json) or /metadata/client.
+type Metadata struct {
+	Type                    SingleSelect
+	Language                SingleSelect
+	Packaging               SingleSelect
+	JavaVersion             SingleSelect
+	BootVersion             SingleSelect
+	ConfigurationFileFormat SingleSelect
+
+	GroupID     TextField
+	ArtifactID  TextField
+	Version     TextField
+	Name        TextField
+	Description TextField
+	PackageName TextField
+
+	Dependencies []DependencyGroup
+
+	// Links are the HAL links of the root document, keyed by relation such
+	// as "maven-project" or "dependencies".
+	Links map[string]Link
+}
+
+// Link is a HAL link. Templated links carry RFC 6570 expressions such as
+// {&dependencies,packaging}.
+type Link struct {
+	Href      string `json:"href"`
+	Templated bool   `json:"templated,omitempty"`
+}
+
+// SingleSelect is a metadata field with a fixed set of values.
+type SingleSelect struct {
+	Default string   `json:"default"`
+	Values  []Option `json:"values"`
+}
+
+// IsBuildType reports whether the project type id generates a lone build
+// file, like pom.xml for maven-build, instead of a project archive. Such
+// types are tagged with format "build".
+func (m *Metadata) IsBuildType(id string) bool {
+	for _, v := range m.Type.Values {
+		if v.ID == id {
+			return v.Tags["format"] == "build"
+		}
+	}
+	return false
+}
+
+// Option is one value of a SingleSelect.
+type Option struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Action is the generation endpoint of a project type, e.g. /starter.zip.
+	Action string `json:"action,omitempty"`
+	// Default marks the default value in older metadata formats.
+	Default bool `json:"default,omitempty"`
+	// Tags classify project types, e.g. {"build": "maven", "format": "project"}.
+	Tags map[string]string `json:"tags,omitempty"`
+}
+
+// IDs returns the IDs of all values in order.
+func (s SingleSelect) IDs() []string {
+	out := make([]string, 0, len(s.Values))
+	for _, v := range s.Values {
+		if v.ID != "" {
+			out = append(out, v.ID)
+		}
+	}
+	return out
+}
+
+// DefaultID returns the default value, honoring per-value default flags when
+// the field has no explicit default.
+func (s SingleSelect) DefaultID() string {
+	if s.Default != "" {
+		return s.Default
+	}
+	for _, v := range s.Values {
+		if v.Default {
+			return v.ID
+		}
+	}
+	return ""
+}
+
+// TextField is a free-form metadata field.
+type TextField struct {
+	Default string `json:"default"`
+}
+
+// DependencyGroup is a named group of dependencies, e.g. "Web".
+type DependencyGroup struct {
+	Name   string       `json:"name"`
+	Values []Dependency `json:"values"`
+}
+
+// Dependency is a selectable project dependency.
+type Dependency struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Description  string `json:"description,omitempty"`
+	VersionRange string `json:"versionRange,omitempty"`
+	// Group is the name of the enclosing DependencyGroup.
+	Group string `json:"group,omitempty"`
+}
+
+// AllDependencies flattens the dependency groups, filling in Group.
+func (m *Metadata) AllDependencies() []Dependency {
+	var out []Dependency
+	for _, g := range m.Dependencies {
+		for _, d := range g.Values {
+			if d.ID == "" {
+				continue
+			}
+			d.Group = g.Name
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// Fingerprint returns a digest of the decoded metadata, "sha256:<hex>". It
+// changes when the server's options, defaults or dependencies change. The
+// links are left out: they name the host the metadata was fetched from.
+func (m *Metadata) Fingerprint() string {
+	c := *m
+	c.Links = nil
+	b, _ := json.Marshal(c)
+	sum := sha256.Sum256(b)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// UnmarshalJSON decodes metadata leniently: a section with an unexpected
+// shape is left empty instead of failing the whole document.
+func (m *Metadata) UnmarshalJSON(b []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	section := func(key string, v any) {
+		if r, ok := raw[key]; ok {
+			json.Unmarshal(r, v)
+		}
+	}
+	section("type", &m.Type)
+	section("language", &m.Language)
+	section("packaging", &m.Packaging)
+	section("javaVersion", &m.JavaVersion)
+	section("bootVersion", &m.BootVersion)
+	section("configurationFileFormat", &m.ConfigurationFileFormat)
+	section("groupId", &m.GroupID)
+	section("artifactId", &m.ArtifactID)
+	section("version", &m.Version)
+	section("name", &m.Name)
+	section("description", &m.Description)
+	section("packageName", &m.PackageName)
+	section("_links", &m.Links)
+
+	var deps struct {
+		Values []struct {
+			Name   string            `json:"name"`
+			Values []json.RawMessage `json:"values"`
+		} `json:"values"`
+	}
+	section("dependencies", &deps)
+	for _, g := range deps.Values {
+		group := DependencyGroup{Name: g.Name}
+		for _, r := range g.Values {
+			var d Dependency
+			if json.Unmarshal(r, &d) == nil && d.ID != "" {
+				group.Values = append(group.Values, d)
+			}
+		}
+		m.Dependencies = append(m.Dependencies, group)
+	}
+	return nil
+}
+
+// Metadata fetches the service metadata, trying the API root first and then
+// the legacy /metadata/client endpoint. A JSON document without project
+// types or dependencies does not count as metadata.
+func (c *Client) Metadata(ctx context.Context) (*Metadata, error) {
+	base, err := c.base()
+	if err != nil {
+		return nil, err
+	}
+	var lastErr error
+	for _, endpoint := range []string{base + "/", base + "/metadata/client"} {
+		var m Metadata
+		if err := c.getJSON(ctx, endpoint, &m); err != nil {
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			lastErr = err
+			continue
+		}
+		if len(m.Type.Values) == 0 && len(m.Dependencies) == 0 {
+			lastErr = fmt.Errorf("no metadata in response from %s", endpoint)
+			continue
+		}
+		return &m, nil
+	}
+	return nil, lastErr
+}
+
+// Dependencies returns the dependency catalog. It is read from the service
+// metadata when available and from the /dependencies endpoint otherwise.
+func (c *Client) Dependencies(ctx context.Context) ([]Dependency, error) {
+	m, err := c.Metadata(ctx)
+	if err != nil && ctx.Err() != nil {
+		return nil, err
+	}
+	return c.DependenciesOf(ctx, m)
+}
+
+// DependenciesOf is Dependencies for callers that already fetched the
+// metadata m, which may be nil. Only when m lists no dependencies is the
+// /dependencies endpoint requested.
+func (c *Client) DependenciesOf(ctx context.Context, m *Metadata) ([]Dependency, error) {
+	base, err := c.base()
+	if err != nil {
+		return nil, err
+	}
+	if m != nil {
+		if deps := m.AllDependencies(); len(deps) > 0 {
+			return deps, nil
+		}
+	}
+	var raw map[string]json.RawMessage
+	if err := c.getJSON(ctx, base+"/dependencies", &raw); err != nil {
+		return nil, err
+	}
+	deps, err := parseDependencyList(raw)
+	if err != nil {
+		return nil, err
+	}
+	if len(deps) == 0 {
+		return nil, fmt.Errorf("no dependencies found from %s", base)
+	}
+	return deps, nil
+}
+
+// parseDependencyList reads the /dependencies shapes: the current
+// {dependencies:{id:{groupId,artifactId}}} coordinates map, which has no
+// names, and the legacy {groups:[{name,values:[{id,name}]}]} and
+// {dependencies:[{id,name,group}]}.
+func parseDependencyList(raw map[string]json.RawMessage) ([]Dependency, error) {
+	if graw, ok := raw["groups"]; ok {
+		var groups []DependencyGroup
+		if err := json.Unmarshal(graw, &groups); err == nil {
+			m := Metadata{Dependencies: groups}
+			return m.AllDependencies(), nil
+		}
+	}
+	if draw, ok := raw["dependencies"]; ok {
+		var deps []Dependency
+		if err := json.Unmarshal(draw, &deps); err == nil {
+			return deps, nil
+		}
+		var coords map[string]Coordinate
+		if err := json.Unmarshal(draw, &coords); err == nil {
+			for id, c := range coords {
+				d := Dependency{ID: id, Name: id}
+				if c.GroupID != "" && c.ArtifactID != "" {
+					d.Description = c.GroupID + ":" + c.ArtifactID
+				}
+				deps = append(deps, d)
+			}
+			sort.Slice(deps, func(i, j int) bool { return deps[i].ID < deps[j].ID })
+			return deps, nil
+		}
+	}
+	return nil, errors.New("unsupported dependencies schema")
+}
+
+// Coordinate is the Maven coordinate a dependency ID resolves to.
+type Coordinate struct {
+	GroupID    string `json:"groupId"`
+	ArtifactID string `json:"artifactId"`
+	Scope      string `json:"scope,omitempty"`
+	Version    string `json:"version,omitempty"`
+}
+
+// DependencyCoordinates returns the coordinates of every dependency ID for
+// bootVersion, or for the server's default version when it is empty, as
+// listed by the /dependencies endpoint.
+func (c *Client) DependencyCoordinates(ctx context.Context, bootVersion string) (map[string]Coordinate, error) {
+	base, err := c.base()
+	if err != nil {
+		return nil, err
+	}
+	u := base + "/dependencies"
+	if v := NormalizeBootVersion(bootVersion); v != "" {
+		u += "?" + url.Values{"bootVersion": {v}}.Encode()
+	}
+	var body struct {
+		Dependencies map[string]Coordinate `json:"dependencies"`
+	}
+	if err := c.getJSON(ctx, u, &body); err != nil {
+		return nil, err
+	}
+	if len(body.Dependencies) == 0 {
+		return nil, fmt.Errorf("no dependency coordinates from %s", u)
+	}
+	return body.Dependencies, nil
+}
+
+func (c *Client) getJSON(ctx context.Context, url string, v any) error {
+	resp, err := c.get(ctx, url, metadataAccept)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,114 @@
+package initializr
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// ProjectRequest describes the project to generate. Empty fields are left
+// out of the request so the server applies its own defaults.
+//
+// The JSON form uses the query parameter names.
+type ProjectRequest struct {
+	Type                    string   `json:"type,omitempty"`        // maven-project, gradle-project, gradle-build, ...
+	Language                string   `json:"language,omitempty"`    // java, kotlin, groovy
+	BootVersion             string   `json:"bootVersion,omitempty"` // normalized with NormalizeBootVersion when sent
+	BaseDir                 string   `json:"baseDir,omitempty"`     // top-level directory inside the archive
+	GroupID                 string   `json:"groupId,omitempty"`
+	ArtifactID              string   `json:"artifactId,omitempty"`
+	Name                    string   `json:"name,omitempty"`
+	Description             string   `json:"description,omitempty"`
+	PackageName             string   `json:"packageName,omitempty"`
+	Packaging               string   `json:"packaging,omitempty"` // jar or war
+	JavaVersion             string   `json:"javaVersion,omitempty"`
+	ConfigurationFileFormat string   `json:"configurationFileFormat,omitempty"` // properties or yaml
+	Dependencies            []string `json:"dependencies,omitempty"`
+}
+
+// Values encodes r as Initializr query parameters.
+func (r ProjectRequest) Values() url.Values {
+	q := url.Values{}
+	add := func(k, v string) {
+		if strings.TrimSpace(v) != "" {
+			q.Set(k, v)
+		}
+	}
+	add("type", r.Type)
+	add("language", r.Language)
+	add("bootVersion", NormalizeBootVersion(r.BootVersion))
+	add("baseDir", r.BaseDir)
+	add("groupId", r.GroupID)
+	add("artifactId", r.ArtifactID)
+	add("name", r.Name)
+	add("description", r.Description)
+	add("packageName", r.PackageName)
+	add("packaging", r.Packaging)
+	add("javaVersion", r.JavaVersion)
+	add("configurationFileFormat", r.ConfigurationFileFormat)
+	var deps []string
+	for _, id := range r.Dependencies {
+		if id = strings.TrimSpace(id); id != "" {
+			deps = append(deps, id)
+		}
+	}
+	if len(deps) > 0 {
+		q.Set("dependencies", strings.Join(deps, ","))
+	}
+	return q
+}
+
+// ParseDependencies splits a comma-separated list of dependency IDs,
+// trimming whitespace and dropping empty entries.
+func ParseDependencies(s string) []string {
+	var ids []string
+	for _, id := range strings.Split(s, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// SanitizePackage normalizes a Java package name typed by a user: it is
+// lower-cased, dashes and spaces are removed and empty segments collapsed.
+func SanitizePackage(s string) string {
+	s = strings.ToLower(s)
+	replacer := strings.NewReplacer("-", "", " ", "")
+	s = replacer.Replace(s)
+	for strings.Contains(s, "..") {
+		s = strings.ReplaceAll(s, "..", ".")
+	}
+	s = strings.Trim(s, ".")
+	return s
+}
+
+var milestoneSuffix = regexp.MustCompile(`\.(M|RC)(\d+)$`)
+
+// NormalizeBootVersion converts historical Spring Boot version notations
+// to the forms accepted by modern Initializr servers.
+// Examples:
+//   - 3.5.5.RELEASE        -> 3.5.5
+//   - 2.0.0.BUILD-SNAPSHOT -> 2.0.0-SNAPSHOT
+//   - 2.0.0.M7             -> 2.0.0-M7
+//   - 2.0.0.RC1            -> 2.0.0-RC1
+func NormalizeBootVersion(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return s
+	}
+	// RELEASE suffix: drop it (accept both ".RELEASE" and "-RELEASE")
+	s = strings.TrimSuffix(s, ".RELEASE")
+	s = strings.TrimSuffix(s, "-RELEASE")
+
+	// BUILD-SNAPSHOT: convert to -SNAPSHOT
+	s = strings.ReplaceAll(s, ".BUILD-SNAPSHOT", "-SNAPSHOT")
+	s = strings.ReplaceAll(s, "-BUILD-SNAPSHOT", "-SNAPSHOT")
+	// Rare: handle ".SNAPSHOT" -> "-SNAPSHOT"
+	if strings.HasSuffix(s, ".SNAPSHOT") {
+		s = strings.TrimSuffix(s, ".SNAPSHOT") + "-SNAPSHOT"
+	}
+
+	// Convert ".M<digits>" and ".RC<digits>" at the end to hyphenated form.
+	return milestoneSuffix.ReplaceAllString(s, "-$1$2")
+}
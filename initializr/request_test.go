@@ -0,0 +1,70 @@
+package initializr
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestSanitizePackage(t *testing.T) {
+	cases := []struct{ in, out string }{
+		{"Com.Example.Demo", "com.example.demo"},
+		{"com-example demo", "comexampledemo"},
+		{"..com..example..demo..", "com.example.demo"},
+		{"-A-.-B-", "a.b"},
+		{"", ""},
+	}
+	for _, c := range cases {
+		if got := SanitizePackage(c.in); got != c.out {
+			t.Errorf("SanitizePackage(%q) = %q; want %q", c.in, got, c.out)
+		}
+	}
+}
+
+func TestNormalizeBootVersion_VariousLegacyForms(t *testing.T) {
+	cases := []struct {
+		in  string
+		out string
+	}{
+		{"3.5.5.RELEASE", "3.5.5"},
+		{"3.5.5-RELEASE", "3.5.5"},
+		{"2.0.0.BUILD-SNAPSHOT", "2.0.0-SNAPSHOT"},
+		{"2.0.0-BUILD-SNAPSHOT", "2.0.0-SNAPSHOT"},
+		{"2.0.0.SNAPSHOT", "2.0.0-SNAPSHOT"},
+		{"2.0.0.M7", "2.0.0-M7"},
+		{"2.0.0.RC1", "2.0.0-RC1"},
+		{"3.3.4", "3.3.4"},
+	}
+	for _, c := range cases {
+		if got := NormalizeBootVersion(c.in); got != c.out {
+			t.Errorf("NormalizeBootVersion(%q) = %q; want %q", c.in, got, c.out)
+		}
+	}
+}
+
+func TestValuesOmitsEmptyFields(t *testing.T) {
+	r := ProjectRequest{
+		Type:         "gradle-project",
+		GroupID:      " ",
+		BootVersion:  "3.5.5.RELEASE",
+		Dependencies: []string{"web", " ", " security "},
+	}
+	want := url.Values{
+		"type":         {"gradle-project"},
+		"bootVersion":  {"3.5.5"},
+		"dependencies": {"web,security"},
+	}
+	if got := r.Values(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Values() = %v; want %v", got, want)
+	}
+}
+
+func TestParseDependencies(t *testing.T) {
+	got := ParseDependencies(" web, ,data-jpa,")
+	if !reflect.DeepEqual(got, []string{"web", "data-jpa"}) {
+		t.Fatalf("ParseDependencies = %q", got)
+	}
+	if ParseDependencies("") != nil {
+		t.Fatal("ParseDependencies(\"\") should be nil")
+	}
+}
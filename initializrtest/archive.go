@@ -0,0 +1,100 @@
+package initializrtest
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// archiveTime is the fixed timestamp of every entry, so identical requests
+// produce byte-identical archives.
+var archiveTime = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// entries expands files into a sorted list of directories and files under
+// baseDir (which may be empty). Absolute or parent-relative parts of baseDir
+// are dropped so entries always stay inside the archive root.
+func entries(baseDir string, files []file) (dirs []string, out []file) {
+	baseDir = strings.TrimLeft(path.Clean("/"+baseDir), "/")
+	seen := map[string]bool{}
+	var addDir func(d string)
+	addDir = func(d string) {
+		if d == "." || d == "/" || d == "" || seen[d] {
+			return
+		}
+		addDir(path.Dir(d))
+		seen[d] = true
+		dirs = append(dirs, d+"/")
+	}
+	for _, f := range files {
+		p := f.path
+		if baseDir != "" {
+			p = path.Join(baseDir, p)
+		}
+		addDir(path.Dir(p))
+		out = append(out, file{path: p, body: f.body, executable: f.executable})
+	}
+	if baseDir != "" {
+		addDir(baseDir)
+	}
+	return dirs, out
+}
+
+func mode(f file) int64 {
+	if f.executable {
+		return 0o755
+	}
+	return 0o644
+}
+
+func writeZip(w io.Writer, baseDir string, files []file) error {
+	zw := zip.NewWriter(w)
+	dirs, files := entries(baseDir, files)
+	for _, d := range dirs {
+		h := &zip.FileHeader{Name: d, Method: zip.Store, Modified: archiveTime}
+		h.SetMode(os.ModeDir | 0o755)
+		if _, err := zw.CreateHeader(h); err != nil {
+			return err
+		}
+	}
+	for _, f := range files {
+		h := &zip.FileHeader{Name: f.path, Method: zip.Deflate, Modified: archiveTime}
+		h.SetMode(os.FileMode(mode(f)))
+		fw, err := zw.CreateHeader(h)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(fw, f.body); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func writeTgz(w io.Writer, baseDir string, files []file) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	dirs, files := entries(baseDir, files)
+	for _, d := range dirs {
+		if err := tw.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: d, Mode: 0o755, ModTime: archiveTime}); err != nil {
+			return err
+		}
+	}
+	for _, f := range files {
+		h := &tar.Header{Typeflag: tar.TypeReg, Name: f.path, Mode: mode(f), Size: int64(len(f.body)), ModTime: archiveTime}
+		if err := tw.WriteHeader(h); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(tw, f.body); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
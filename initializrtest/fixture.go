@@ -0,0 +1,192 @@
+package initializrtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// Cassette is a set of recorded HTTP exchanges with an Initializr server.
+// Cassettes are stored as indented JSON so that fixture changes review well.
+type Cassette struct {
+	// BaseURL is the server the exchanges were recorded from.
+	BaseURL string `json:"baseUrl"`
+	// Recorded is the recording date (YYYY-MM-DD).
+	Recorded     string        `json:"recorded,omitempty"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is one recorded request and its response.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest identifies a request by method, path and query.
+type RecordedRequest struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Query  string `json:"query,omitempty"`
+	// Accept is informational; replay does not match on it.
+	Accept string `json:"accept,omitempty"`
+}
+
+// RecordedResponse is a response. JSON bodies are kept as JSON; other
+// bodies are stored as text.
+type RecordedResponse struct {
+	Status      int             `json:"status"`
+	ContentType string          `json:"contentType,omitempty"`
+	JSON        json.RawMessage `json:"json,omitempty"`
+	Text        string          `json:"text,omitempty"`
+}
+
+// LoadCassette reads a cassette file.
+func LoadCassette(path string) (*Cassette, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c Cassette
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &c, nil
+}
+
+// Save writes the cassette to path.
+func (c *Cassette) Save(path string) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false) // keep URL templates such as {&dependencies} readable
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(c); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// Recorder is an http.RoundTripper that forwards requests to Next and
+// appends every exchange to Cassette.
+type Recorder struct {
+	Next http.RoundTripper // nil means http.DefaultTransport
+	// Accept, when set, replaces the request's Accept header so that a
+	// specific metadata version can be recorded.
+	Accept   string
+	Cassette *Cassette
+
+	mu sync.Mutex
+}
+
+// NewRecorder returns a Recorder filling a new cassette for baseURL.
+func NewRecorder(baseURL string) *Recorder {
+	return &Recorder{Cassette: &Cassette{
+		BaseURL:  baseURL,
+		Recorded: time.Now().UTC().Format(time.DateOnly),
+	}}
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	if r.Accept != "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("Accept", r.Accept)
+	}
+	next := r.Next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	resp, err := next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	rec := RecordedResponse{Status: resp.StatusCode, ContentType: resp.Header.Get("Content-Type")}
+	if json.Valid(body) {
+		rec.JSON = body
+	} else {
+		rec.Text = string(body)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Cassette.Interactions = append(r.Cassette.Interactions, Interaction{
+		Request: RecordedRequest{
+			Method: req.Method,
+			Path:   req.URL.Path,
+			Query:  normalizeQuery(req.URL.RawQuery),
+			Accept: req.Header.Get("Accept"),
+		},
+		Response: rec,
+	})
+	return resp, nil
+}
+
+// Replay returns an http.RoundTripper that answers from c, whatever the host
+// of the request. A request with no recorded exchange gets the JSON 404 a
+// server without that endpoint sends, so a cassette can leave out endpoints
+// an older server did not have.
+func Replay(c *Cassette) http.RoundTripper {
+	return replay{c}
+}
+
+type replay struct{ c *Cassette }
+
+func (r replay) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	query := normalizeQuery(req.URL.RawQuery)
+	for _, in := range r.c.Interactions {
+		if in.Request.Method == req.Method && in.Request.Path == req.URL.Path && in.Request.Query == query {
+			return in.Response.response(req), nil
+		}
+	}
+	rec := httptest.NewRecorder()
+	writeError(rec, req, http.StatusNotFound, "No endpoint "+req.URL.Path)
+	return rec.Result(), nil
+}
+
+func (rr RecordedResponse) response(req *http.Request) *http.Response {
+	body := []byte(rr.Text)
+	if len(rr.JSON) > 0 {
+		body = rr.JSON
+	}
+	h := http.Header{}
+	if rr.ContentType != "" {
+		h.Set("Content-Type", rr.ContentType)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", rr.Status, http.StatusText(rr.Status)),
+		StatusCode:    rr.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        h,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// normalizeQuery sorts the query so that parameter order does not matter.
+func normalizeQuery(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	v, err := url.ParseQuery(raw)
+	if err != nil {
+		return raw
+	}
+	return v.Encode()
+}
@@ -0,0 +1,215 @@
+// Package initializrtest provides an offline stand-in for a Spring Initializr
+// server. It serves recorded metadata and generates small, deterministic
+// project archives so that the CLI and TUI can be demoed and tested without
+// network access.
+package initializrtest
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+)
+
+// recordedHost is the origin used in the recorded metadata links. It is
+// rewritten to the serving host so links point back at the mock.
+const recordedHost = "https://start.spring.io"
+
+//go:embed metadata.json
+var metadataJSON []byte
+
+//go:embed dependencies.json
+var dependenciesJSON []byte
+
+// Dependency is the Maven coordinate of an Initializr dependency ID.
+type Dependency struct {
+	GroupID    string `json:"groupId"`
+	ArtifactID string `json:"artifactId"`
+	Scope      string `json:"scope"`
+}
+
+// NewServer starts a mock Initializr server. Callers must Close it.
+func NewServer() *httptest.Server {
+	return httptest.NewServer(NewHandler())
+}
+
+// NewHandler returns the mock Initializr as an http.Handler. It serves:
+//
+//   - / and /metadata/client: recorded v2.3 metadata
+//   - /dependencies: dependency coordinates
+//   - /starter.zip, /starter.tgz: generated project archives
+//   - /pom.xml, /build.gradle: generated build files
+func NewHandler() http.Handler {
+	var deps struct {
+		Dependencies map[string]Dependency `json:"dependencies"`
+	}
+	if err := json.Unmarshal(dependenciesJSON, &deps); err != nil {
+		panic("initializrtest: bad embedded dependencies: " + err.Error())
+	}
+	h := &handler{deps: deps.Dependencies}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", h.metadata)
+	mux.HandleFunc("/metadata/client", h.metadata)
+	mux.HandleFunc("/dependencies", h.dependencies)
+	mux.HandleFunc("/starter.zip", h.starter)
+	mux.HandleFunc("/starter.tgz", h.starter)
+	mux.HandleFunc("/pom.xml", h.buildFile)
+	mux.HandleFunc("/build.gradle", h.buildFile)
+	return mux
+}
+
+type handler struct {
+	deps map[string]Dependency
+}
+
+func (h *handler) metadata(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" && r.URL.Path != "/metadata/client" {
+		writeError(w, r, http.StatusNotFound, "No endpoint "+r.URL.Path)
+		return
+	}
+	body := bytes.ReplaceAll(metadataJSON, []byte(recordedHost), []byte(origin(r)))
+	w.Header().Set("Content-Type", "application/vnd.initializr.v2.3+json")
+	w.Write(body)
+}
+
+func (h *handler) dependencies(w http.ResponseWriter, r *http.Request) {
+	body := dependenciesJSON
+	if v := r.URL.Query().Get("bootVersion"); v != "" {
+		var doc map[string]json.RawMessage
+		if err := json.Unmarshal(dependenciesJSON, &doc); err == nil {
+			doc["bootVersion"], _ = json.Marshal(v)
+			body, _ = json.Marshal(doc)
+		}
+	}
+	w.Header().Set("Content-Type", "application/vnd.initializr.v2.2+json")
+	w.Write(body)
+}
+
+func (h *handler) starter(w http.ResponseWriter, r *http.Request) {
+	p, err := h.project(r)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	files := p.files()
+	var buf bytes.Buffer
+	if strings.HasSuffix(r.URL.Path, ".tgz") {
+		err = writeTgz(&buf, p.baseDir, files)
+		w.Header().Set("Content-Type", "application/x-compress")
+	} else {
+		err = writeZip(&buf, p.baseDir, files)
+		w.Header().Set("Content-Type", "application/zip")
+	}
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+	name := p.artifactID
+	if p.baseDir != "" {
+		name = p.baseDir
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+r.URL.Path[len("/starter"):]))
+	w.Header().Set("Content-Length", fmt.Sprint(buf.Len()))
+	w.Write(buf.Bytes())
+}
+
+func (h *handler) buildFile(w http.ResponseWriter, r *http.Request) {
+	p, err := h.project(r)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if r.URL.Path == "/pom.xml" {
+		w.Header().Set("Content-Type", "application/xml")
+		w.Write(p.pom())
+		return
+	}
+	w.Write(p.buildGradle())
+}
+
+// project validates the query like the real server and resolves defaults.
+func (h *handler) project(r *http.Request) (*project, error) {
+	q := r.URL.Query()
+	get := func(key, def string) string {
+		if v := strings.TrimSpace(q.Get(key)); v != "" {
+			return v
+		}
+		return def
+	}
+	p := &project{
+		typ:         get("type", "maven-project"),
+		language:    get("language", "java"),
+		bootVersion: get("bootVersion", "3.5.5"),
+		baseDir:     q.Get("baseDir"),
+		groupID:     get("groupId", "com.example"),
+		artifactID:  get("artifactId", "demo"),
+		version:     get("version", "0.0.1-SNAPSHOT"),
+		name:        get("name", "demo"),
+		description: get("description", "Demo project for Spring Boot"),
+		packaging:   get("packaging", "jar"),
+		javaVersion: get("javaVersion", "17"),
+		configFmt:   get("configurationFileFormat", "properties"),
+	}
+	p.packageName = get("packageName", p.groupID+"."+p.artifactID)
+
+	switch p.typ {
+	case "maven-project", "maven-build", "gradle-project", "gradle-project-kotlin", "gradle-build":
+	default:
+		return nil, fmt.Errorf("Unknown type '%s' check project metadata", p.typ)
+	}
+	switch p.language {
+	case "java", "kotlin", "groovy":
+	default:
+		return nil, fmt.Errorf("Unknown language '%s' check project metadata", p.language)
+	}
+	switch p.packaging {
+	case "jar", "war":
+	default:
+		return nil, fmt.Errorf("Unknown packaging '%s' check project metadata", p.packaging)
+	}
+	ids := []string{}
+	for _, v := range q["dependencies"] {
+		for _, id := range strings.Split(v, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				ids = append(ids, id)
+			}
+		}
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		d, ok := h.deps[id]
+		if !ok {
+			return nil, fmt.Errorf("Unknown dependency '%s' check project metadata", id)
+		}
+		p.deps = append(p.deps, d)
+	}
+	return p, nil
+}
+
+// origin returns the scheme and host the request was addressed to.
+func origin(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+// writeError mimics the Spring Boot JSON error body of the real service.
+func writeError(w http.ResponseWriter, r *http.Request, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{
+		"timestamp": "2025-01-01T00:00:00.000+00:00",
+		"status":    status,
+		"error":     http.StatusText(status),
+		"message":   msg,
+		"path":      r.URL.Path,
+	})
+}
@@ -0,0 +1,80 @@
+package initializrtest
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func get(t *testing.T, url string) (*http.Response, []byte) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, b
+}
+
+func TestStarterZipIsDeterministic(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	u := srv.URL + "/starter.zip?type=gradle-project&baseDir=demo&dependencies=web,postgresql"
+	_, a := get(t, u)
+	_, b := get(t, u)
+	if !bytes.Equal(a, b) {
+		t.Fatal("identical requests produced different archives")
+	}
+	zr, err := zip.NewReader(bytes.NewReader(a), int64(len(a)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var build string
+	for _, f := range zr.File {
+		if f.Name == "demo/build.gradle" {
+			rc, _ := f.Open()
+			bb, _ := io.ReadAll(rc)
+			rc.Close()
+			build = string(bb)
+		}
+	}
+	if !strings.Contains(build, "implementation 'org.springframework.boot:spring-boot-starter-web'") ||
+		!strings.Contains(build, "runtimeOnly 'org.postgresql:postgresql'") {
+		t.Fatalf("unexpected build.gradle:\n%s", build)
+	}
+}
+
+func TestUnknownDependencyIsRejected(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	resp, body := get(t, srv.URL+"/starter.zip?dependencies=web,nope")
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("status = %d; want 400", resp.StatusCode)
+	}
+	if !strings.Contains(string(body), "Unknown dependency 'nope'") {
+		t.Fatalf("unexpected body: %s", body)
+	}
+}
+
+func TestMetadataLinksPointAtServer(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	_, body := get(t, srv.URL+"/")
+	if strings.Contains(string(body), recordedHost) || !strings.Contains(string(body), srv.URL+"/starter.zip") {
+		t.Fatal("metadata links were not rewritten to the mock host")
+	}
+}
+
+func TestEntriesStayInsideArchive(t *testing.T) {
+	dirs, files := entries("/tmp/../demo", []file{{path: "pom.xml"}})
+	if len(dirs) != 1 || dirs[0] != "demo/" || files[0].path != "demo/pom.xml" {
+		t.Fatalf("dirs=%v files=%v", dirs, files)
+	}
+}
@@ -0,0 +1,251 @@
+package initializrtest
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// project is a resolved starter request.
+type project struct {
+	typ         string
+	language    string
+	bootVersion string
+	baseDir     string
+	groupID     string
+	artifactID  string
+	version     string
+	name        string
+	description string
+	packageName string
+	packaging   string
+	javaVersion string
+	configFmt   string
+	deps        []Dependency
+}
+
+// file is one archive entry.
+type file struct {
+	path       string
+	body       string
+	executable bool
+}
+
+// toolVersions returns the Maven and Gradle wrapper versions the real service
+// ships with a given Spring Boot generation.
+func toolVersions(bootVersion string) (maven, gradle string) {
+	switch {
+	case strings.HasPrefix(bootVersion, "3.4."):
+		return "3.9.9", "8.10.2"
+	case strings.HasPrefix(bootVersion, "4."):
+		return "3.9.11", "9.0.0"
+	default:
+		return "3.9.11", "8.14.3"
+	}
+}
+
+func (p *project) gradle() bool { return strings.HasPrefix(p.typ, "gradle") }
+
+func (p *project) kotlinDSL() bool { return p.typ == "gradle-project-kotlin" }
+
+// applicationName derives the main class name, e.g. "my-demo" -> "MyDemoApplication".
+func (p *project) applicationName() string {
+	var b strings.Builder
+	upper := true
+	for _, r := range p.name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	if b.Len() == 0 {
+		return "Application"
+	}
+	return b.String() + "Application"
+}
+
+// files lists the project files in a stable order.
+func (p *project) files() []file {
+	maven, gradle := toolVersions(p.bootVersion)
+	ext := map[string]string{"java": "java", "kotlin": "kt", "groovy": "groovy"}[p.language]
+	pkgPath := strings.ReplaceAll(p.packageName, ".", "/")
+	app := p.applicationName()
+
+	files := []file{
+		{path: ".gitattributes", body: "/mvnw text eol=lf\n*.cmd text eol=crlf\n"},
+		{path: ".gitignore", body: "HELP.md\ntarget/\nbuild/\n.gradle/\n.idea/\n*.iml\n"},
+		{path: "HELP.md", body: "# Getting Started\n\n### Reference Documentation\n\n* [Official Gradle documentation](https://docs.gradle.org)\n* [Spring Boot Maven Plugin Reference Guide](https://docs.spring.io/spring-boot/" + p.bootVersion + "/maven-plugin)\n"},
+		{path: "src/main/" + p.language + "/" + pkgPath + "/" + app + "." + ext, body: p.mainClass(app)},
+		{path: "src/test/" + p.language + "/" + pkgPath + "/" + app + "Tests." + ext, body: p.testClass(app)},
+	}
+	if p.configFmt == "yaml" {
+		files = append(files, file{path: "src/main/resources/application.yaml", body: "spring:\n  application:\n    name: " + p.name + "\n"})
+	} else {
+		files = append(files, file{path: "src/main/resources/application.properties", body: "spring.application.name=" + p.name + "\n"})
+	}
+	if p.packaging == "war" {
+		files = append(files, file{path: "src/main/" + p.language + "/" + pkgPath + "/ServletInitializer." + ext, body: p.servletInitializer(app)})
+	}
+	if p.gradle() {
+		build, settings := "build.gradle", "settings.gradle"
+		if p.kotlinDSL() {
+			build, settings = "build.gradle.kts", "settings.gradle.kts"
+		}
+		files = append(files,
+			file{path: build, body: string(p.buildGradle())},
+			file{path: settings, body: fmt.Sprintf("rootProject.name = '%s'\n", p.artifactID)},
+			file{path: "gradlew", body: "#!/bin/sh\n# Gradle start up script (mock)\nexec java -classpath gradle/wrapper/gradle-wrapper.jar org.gradle.wrapper.GradleWrapperMain \"$@\"\n", executable: true},
+			file{path: "gradlew.bat", body: "@rem Gradle startup script for Windows (mock)\r\n"},
+			file{path: "gradle/wrapper/gradle-wrapper.properties", body: "distributionBase=GRADLE_USER_HOME\ndistributionPath=wrapper/dists\ndistributionUrl=https\\://services.gradle.org/distributions/gradle-" + gradle + "-bin.zip\nnetworkTimeout=10000\nvalidateDistributionUrl=true\nzipStoreBase=GRADLE_USER_HOME\nzipStorePath=wrapper/dists\n"},
+		)
+	} else {
+		files = append(files,
+			file{path: "pom.xml", body: string(p.pom())},
+			file{path: "mvnw", body: "#!/bin/sh\n# Apache Maven Wrapper startup script (mock)\nexec mvn \"$@\"\n", executable: true},
+			file{path: "mvnw.cmd", body: "@REM Apache Maven Wrapper startup batch script (mock)\r\n"},
+			file{path: ".mvn/wrapper/maven-wrapper.properties", body: "wrapperVersion=3.3.2\ndistributionType=only-script\ndistributionUrl=https://repo.maven.apache.org/maven2/org/apache/maven/apache-maven/" + maven + "/apache-maven-" + maven + "-bin.zip\n"},
+		)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].path < files[j].path })
+	return files
+}
+
+func (p *project) pom() []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<?xml version="1.0" encoding="UTF-8"?>
+<project xmlns="http://maven.apache.org/POM/4.0.0" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"
+	xsi:schemaLocation="http://maven.apache.org/POM/4.0.0 https://maven.apache.org/xsd/maven-4.0.0.xsd">
+	<modelVersion>4.0.0</modelVersion>
+	<parent>
+		<groupId>org.springframework.boot</groupId>
+		<artifactId>spring-boot-starter-parent</artifactId>
+		<version>%s</version>
+		<relativePath/> <!-- lookup parent from repository -->
+	</parent>
+	<groupId>%s</groupId>
+	<artifactId>%s</artifactId>
+	<version>%s</version>
+`, p.bootVersion, p.groupID, p.artifactID, p.version)
+	if p.packaging == "war" {
+		b.WriteString("\t<packaging>war</packaging>\n")
+	}
+	fmt.Fprintf(&b, `	<name>%s</name>
+	<description>%s</description>
+	<properties>
+		<java.version>%s</java.version>
+	</properties>
+	<dependencies>
+`, p.name, p.description, p.javaVersion)
+	for _, d := range p.buildDeps() {
+		fmt.Fprintf(&b, "\t\t<dependency>\n\t\t\t<groupId>%s</groupId>\n\t\t\t<artifactId>%s</artifactId>\n", d.GroupID, d.ArtifactID)
+		switch d.Scope {
+		case "runtime", "test", "provided":
+			fmt.Fprintf(&b, "\t\t\t<scope>%s</scope>\n", d.Scope)
+		case "annotationProcessor":
+			b.WriteString("\t\t\t<optional>true</optional>\n")
+		}
+		b.WriteString("\t\t</dependency>\n")
+	}
+	b.WriteString(`	</dependencies>
+
+	<build>
+		<plugins>
+			<plugin>
+				<groupId>org.springframework.boot</groupId>
+				<artifactId>spring-boot-maven-plugin</artifactId>
+			</plugin>
+		</plugins>
+	</build>
+
+</project>
+`)
+	return []byte(b.String())
+}
+
+func (p *project) buildGradle() []byte {
+	var b strings.Builder
+	if p.kotlinDSL() {
+		fmt.Fprintf(&b, "plugins {\n\tjava\n\tid(\"org.springframework.boot\") version \"%s\"\n\tid(\"io.spring.dependency-management\") version \"1.1.7\"\n}\n\n", p.bootVersion)
+		fmt.Fprintf(&b, "group = \"%s\"\nversion = \"%s\"\ndescription = \"%s\"\n\n", p.groupID, p.version, p.description)
+		fmt.Fprintf(&b, "java {\n\ttoolchain {\n\t\tlanguageVersion = JavaLanguageVersion.of(%s)\n\t}\n}\n\n", p.javaVersion)
+		b.WriteString("repositories {\n\tmavenCentral()\n}\n\ndependencies {\n")
+		for _, d := range p.buildDeps() {
+			fmt.Fprintf(&b, "\t%s(\"%s:%s\")\n", gradleConfiguration(d.Scope), d.GroupID, d.ArtifactID)
+		}
+		b.WriteString("}\n\ntasks.withType<Test> {\n\tuseJUnitPlatform()\n}\n")
+		return []byte(b.String())
+	}
+	fmt.Fprintf(&b, "plugins {\n\tid 'java'\n\tid 'org.springframework.boot' version '%s'\n\tid 'io.spring.dependency-management' version '1.1.7'\n}\n\n", p.bootVersion)
+	fmt.Fprintf(&b, "group = '%s'\nversion = '%s'\ndescription = '%s'\n\n", p.groupID, p.version, p.description)
+	fmt.Fprintf(&b, "java {\n\ttoolchain {\n\t\tlanguageVersion = JavaLanguageVersion.of(%s)\n\t}\n}\n\n", p.javaVersion)
+	b.WriteString("repositories {\n\tmavenCentral()\n}\n\ndependencies {\n")
+	for _, d := range p.buildDeps() {
+		fmt.Fprintf(&b, "\t%s '%s:%s'\n", gradleConfiguration(d.Scope), d.GroupID, d.ArtifactID)
+	}
+	b.WriteString("}\n\ntasks.named('test') {\n\tuseJUnitPlatform()\n}\n")
+	return []byte(b.String())
+}
+
+// buildDeps adds the implicit starters the real service always includes.
+func (p *project) buildDeps() []Dependency {
+	deps := append([]Dependency(nil), p.deps...)
+	hasStarter := false
+	for _, d := range deps {
+		if d.GroupID == "org.springframework.boot" && strings.HasPrefix(d.ArtifactID, "spring-boot-starter") {
+			hasStarter = true
+		}
+	}
+	if !hasStarter {
+		deps = append([]Dependency{{GroupID: "org.springframework.boot", ArtifactID: "spring-boot-starter", Scope: "compile"}}, deps...)
+	}
+	if p.packaging == "war" {
+		deps = append(deps, Dependency{GroupID: "org.springframework.boot", ArtifactID: "spring-boot-starter-tomcat", Scope: "provided"})
+	}
+	return append(deps, Dependency{GroupID: "org.springframework.boot", ArtifactID: "spring-boot-starter-test", Scope: "test"})
+}
+
+func gradleConfiguration(scope string) string {
+	switch scope {
+	case "runtime":
+		return "runtimeOnly"
+	case "test":
+		return "testImplementation"
+	case "provided":
+		return "providedRuntime"
+	case "annotationProcessor":
+		return "annotationProcessor"
+	default:
+		return "implementation"
+	}
+}
+
+func (p *project) mainClass(app string) string {
+	switch p.language {
+	case "kotlin":
+		return fmt.Sprintf("package %s\n\nimport org.springframework.boot.autoconfigure.SpringBootApplication\nimport org.springframework.boot.runApplication\n\n@SpringBootApplication\nclass %s\n\nfun main(args: Array<String>) {\n\trunApplication<%s>(*args)\n}\n", p.packageName, app, app)
+	default:
+		semi := ";"
+		if p.language == "groovy" {
+			semi = ""
+		}
+		return fmt.Sprintf("package %s%s\n\nimport org.springframework.boot.SpringApplication%s\nimport org.springframework.boot.autoconfigure.SpringBootApplication%s\n\n@SpringBootApplication\npublic class %s {\n\n\tpublic static void main(String[] args) {\n\t\tSpringApplication.run(%s.class, args)%s\n\t}\n\n}\n", p.packageName, semi, semi, semi, app, app, semi)
+	}
+}
+
+func (p *project) testClass(app string) string {
+	semi := ";"
+	if p.language != "java" {
+		semi = ""
+	}
+	return fmt.Sprintf("package %s%s\n\nimport org.junit.jupiter.api.Test%s\nimport org.springframework.boot.test.context.SpringBootTest%s\n\n@SpringBootTest\nclass %sTests {\n\n\t@Test\n\tvoid contextLoads() {\n\t}\n\n}\n", p.packageName, semi, semi, semi, app)
+}
+
+func (p *project) servletInitializer(app string) string {
+	return fmt.Sprintf("package %s;\n\nimport org.springframework.boot.builder.SpringApplicationBuilder;\nimport org.springframework.boot.web.servlet.support.SpringBootServletInitializer;\n\npublic class ServletInitializer extends SpringBootServletInitializer {\n\n\t@Override\n\tprotected SpringApplicationBuilder configure(SpringApplicationBuilder application) {\n\t\treturn application.sources(%s.class);\n\t}\n\n}\n", p.packageName, app)
+}
@@ -45,5 +45,8 @@ func printLicenses() {
     println("- github.com/rivo/tview (MIT License)")
     println("  See: https://github.com/rivo/tview")
     println("\n-- tview License (MIT) --\n" + tviewLicense)
+    println("\n- github.com/go-git/go-git/v5 (Apache License 2.0)")
+    println("  See: https://github.com/go-git/go-git")
+    println("  License: https://www.apache.org/licenses/LICENSE-2.0")
 }
 
@@ -1,35 +1,37 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"os/signal"
 	"strings"
-	"time"
+	"syscall"
+
+	"github.com/mikoto2000/spring-initializr-cli/initializr"
 )
 
-const defaultBaseURL = "https://start.spring.io"
+const defaultBaseURL = initializr.DefaultBaseURL
 
 // version is set at build time via -ldflags "-X main.version=<version>"
 var version = "dev"
 
 type options struct {
-	baseURL          string
-	target           string // zip or tgz (only zip implemented for now)
-	projectType      string // maven-project, gradle-project, gradle-build
-	language         string // java, kotlin, groovy
-	bootVersion      string
-	groupID          string
-	artifactID       string
-	name             string
-	description      string
-	packageName      string
-	packaging        string // jar or war
-	javaVersion      string // 8, 11, 17, 21, etc.
-	configFileFormat string // properties or yaml
-	dependencies     string // comma-separated
-	baseDir          string
+	// ProjectRequest holds the project settings sent to the server.
+	initializr.ProjectRequest
+
+	baseURL string
+	target  string // zip or tgz (only zip implemented for now)
+
+	// transport configures proxy and TLS; httpTransport is built from it
+	// once and shared by every request.
+	transport     transportConfig
+	auth          authConfig
+	httpTransport http.RoundTripper
 
 	output  string // output file path for zip
 	extract bool   // extract zip to directory (baseDir)
@@ -37,6 +39,31 @@ type options struct {
 	timeout int    // seconds
 	verbose bool
 
+	// outputSet reports --output on the command line; output then names a
+	// lone build file too, instead of its default <artifactId>.zip.
+	outputSet bool
+
+	// debugHTTP logs every request and response to debugOut (nil means
+	// os.Stderr).
+	debugHTTP bool
+	debugOut  io.Writer
+
+	// print the start.spring.io web UI link and exit
+	printShareURL bool
+
+	// writeDescriptor records the request in <baseDir>/.initializr.json
+	// after extraction; expectFingerprint is the metadata fingerprint of a
+	// descriptor given with --from-descriptor.
+	writeDescriptor   bool
+	expectFingerprint string
+
+	// mirrors, overlay and git post-process the project extracted into
+	// baseDir: downloads are pointed at mirrors, template trees are copied
+	// onto it, then a repository is set up.
+	mirrors mirrorConfig
+	overlay overlayConfig
+	git     gitConfig
+
 	// interactive control (not a flag)
 	interactive bool
 
@@ -48,8 +75,23 @@ type options struct {
 }
 
 func main() {
-	opts := parseFlags()
-	if err := run(opts); err != nil {
+	// Ctrl+C or SIGTERM cancels ctx so downloads clean up after themselves.
+	// A second signal terminates immediately.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	context.AfterFunc(ctx, stop)
+
+	var err error
+	if cmd, ok := lookupSubcommand(os.Args[1:]); ok {
+		err = cmd(ctx, os.Args[2:])
+	} else {
+		err = run(ctx, parseFlags())
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			fmt.Fprintln(os.Stderr, "interrupted")
+			os.Exit(130)
+		}
 		fmt.Fprintln(os.Stderr, "error:", err)
 		os.Exit(1)
 	}
@@ -131,7 +173,7 @@ func main() {
 		return o
 	}
 */
-func run(o options) error {
+func run(ctx context.Context, o options) error {
 	if o.showVersion {
 		fmt.Println(version)
 		return nil
@@ -140,81 +182,110 @@ func run(o options) error {
 		printLicenses()
 		return nil
 	}
+	if o.printShareURL {
+		fmt.Println(redactURL(shareURL(o)))
+		return nil
+	}
+	if o.debugHTTP && o.interactive && o.debugOut == nil {
+		// The TUI owns the terminal; print the log once it has exited.
+		var buf bytes.Buffer
+		o.debugOut = &buf
+		defer func() { os.Stderr.Write(buf.Bytes()) }()
+	}
+	if err := o.prepareTransport(); err != nil {
+		return err
+	}
 	if o.interactive {
 		// Use the full-featured TUI if available
-		return runInteractive(o)
+		return runInteractive(ctx, o)
 	}
 	if strings.ToLower(o.target) != "zip" {
 		return fmt.Errorf("unsupported target '%s' (only 'zip' is supported)", o.target)
 	}
 
-	u, err := buildURL(o)
-	if err != nil {
-		return err
-	}
-
 	if o.dryRun {
-		fmt.Println(u)
+		// The URL a download would use; without the server's metadata it is
+		// built offline, as a download would fall back to.
+		p, err := resolveProject(ctx, o)
+		if err != nil {
+			return err
+		}
+		fmt.Println(redactURL(p.url))
 		return nil
 	}
 
-	if o.verbose {
-		fmt.Println("Downloading:", u)
+	if o.writeDescriptor && !o.extract {
+		return errors.New("--write-descriptor requires --extract")
+	}
+	if err := o.mirrors.check(o.extract); err != nil {
+		return err
+	}
+	if err := o.overlay.check(o.extract); err != nil {
+		return err
+	}
+	if err := o.git.check(o.extract); err != nil {
+		return err
 	}
 
-	client := &http.Client{Timeout: time.Duration(o.timeout) * time.Second}
-	req, err := http.NewRequest(http.MethodGet, u, nil)
+	p, err := resolveProject(ctx, o)
 	if err != nil {
 		return err
 	}
-	req.Header.Set("Accept", "application/zip, application/octet-stream")
+	if o.verbose {
+		fmt.Println("Downloading:", redactURL(p.url))
+	}
+	if metadataChanged(o, p) {
+		fmt.Fprintln(os.Stderr, "warning: the server's metadata changed since the descriptor was written; the generated project may differ")
+	}
 
-	resp, err := client.Do(req)
+	progress, finish := newStderrProgress()
+	res, err := downloadFrom(ctx, o, p, progress)
+	finish()
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		b, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
-		return fmt.Errorf("bad response: %s\n%s", resp.Status, string(b))
+	if res.gitEnclosing != "" {
+		fmt.Fprintf(os.Stderr, "%s is already inside the git repository at %s; skipped --git-init\n", res.path, res.gitEnclosing)
 	}
+	if o.verbose {
+		fmt.Println(res.summary())
+	}
+	return nil
+}
 
-	if o.extract {
-		// Download to temp file, then unzip into baseDir
-		tmpf, err := os.CreateTemp("", "spring-initializr-*.zip")
-		if err != nil {
-			return err
-		}
-		tmp := tmpf.Name()
-		if o.verbose {
-			fmt.Println("Saving to temp:", tmp)
-		}
-		if _, err := io.Copy(tmpf, resp.Body); err != nil {
-			tmpf.Close()
-			os.Remove(tmp)
-			return err
+// prepareTransport builds the shared HTTP transport from o.transport, adds
+// the credentials from o.auth for the base URL's host and, with --debug-http,
+// logs the traffic.
+func (o *options) prepareTransport() error {
+	rt, err := o.transport.roundTripper()
+	if err != nil {
+		return err
+	}
+	if o.transport.insecureSkipTLSVerify {
+		fmt.Fprintln(os.Stderr, "warning: TLS certificate verification is disabled (--insecure-skip-tls-verify)")
+	}
+	creds, err := o.auth.resolve(o.baseURL)
+	if err != nil {
+		return err
+	}
+	if o.debugHTTP {
+		out := o.debugOut
+		if out == nil {
+			out = os.Stderr
 		}
-		tmpf.Close()
-
-		if err := unzip(tmp, o.baseDir); err != nil {
-			os.Remove(tmp)
-			return err
+		var redact []string
+		if creds != nil {
+			redact = creds.headerNames
 		}
-		os.Remove(tmp)
+		rt = newDebugTransport(rt, out, redact)
+	}
+	if creds != nil {
+		rt = &authTransport{creds: creds, next: rt}
 		if o.verbose {
-			fmt.Println("Extracted into:", o.baseDir)
+			fmt.Fprintln(os.Stderr, "Auth:", creds.describe())
 		}
-		return nil
-	}
-
-	// Save zip to file
-	if err := saveToFile(resp.Body, o.output); err != nil {
-		return err
-	}
-	if o.verbose {
-		fmt.Println("Saved:", o.output)
 	}
+	o.httpTransport = rt
 	return nil
 }
 
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// The public locations generated wrappers download from.
+const (
+	mavenCentralURL        = "https://repo.maven.apache.org/maven2"
+	gradleDistributionsURL = "https://services.gradle.org/distributions"
+)
+
+// mirrorConfig points generated projects at internal mirrors: maven replaces
+// Maven Central, for the Maven wrapper and the build's repositories, and
+// gradle replaces the Gradle distributions site in the Gradle wrapper.
+type mirrorConfig struct {
+	maven  string
+	gradle string
+}
+
+// registerMirrorFlags adds the mirror flags to fs.
+func registerMirrorFlags(fs *flag.FlagSet, m *mirrorConfig) {
+	fs.StringVar(&m.maven, "maven-mirror", "", "Maven repository mirroring Maven Central, set in the generated wrapper and build file")
+	fs.StringVar(&m.gradle, "gradle-mirror", "", "Mirror of "+gradleDistributionsURL+", set in the generated Gradle wrapper")
+}
+
+// check reports mirrors that are not http(s) URLs, and mirrors without
+// extract: the downloaded archive is saved as it is.
+func (m mirrorConfig) check(extract bool) error {
+	if m.enabled() && !extract {
+		return errors.New("--maven-mirror and --gradle-mirror require --extract")
+	}
+	for _, f := range []struct{ name, value string }{{"maven-mirror", m.maven}, {"gradle-mirror", m.gradle}} {
+		if f.value == "" {
+			continue
+		}
+		u, err := url.Parse(f.value)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid --%s %q: want an http(s) URL", f.name, f.value)
+		}
+	}
+	return nil
+}
+
+func (m mirrorConfig) enabled() bool { return m.maven != "" || m.gradle != "" }
+
+// rewrite returns data, the content of the project file at path, changed to
+// use the mirrors. Files already using them come back unchanged.
+func (m mirrorConfig) rewrite(path string, data []byte) []byte {
+	maven := strings.TrimRight(m.maven, "/")
+	gradle := strings.TrimRight(m.gradle, "/")
+	s := string(data)
+	switch {
+	case path == ".mvn/wrapper/maven-wrapper.properties" && maven != "":
+		s = strings.ReplaceAll(s, mavenCentralURL+"/", maven+"/")
+	case path == "gradle/wrapper/gradle-wrapper.properties" && gradle != "":
+		// Properties files escape the colon, as the generated one does.
+		escaped := strings.ReplaceAll(gradle, ":", `\:`)
+		s = strings.ReplaceAll(s, strings.ReplaceAll(gradleDistributionsURL, ":", `\:`)+"/", escaped+"/")
+		s = strings.ReplaceAll(s, gradleDistributionsURL+"/", escaped+"/")
+	case path == "pom.xml" && maven != "":
+		s = injectPomRepositories(s, maven)
+	case path == "build.gradle" && maven != "":
+		s = injectGradleRepository(s, "maven { url '"+maven+"' }")
+	case path == "build.gradle.kts" && maven != "":
+		s = injectGradleRepository(s, `maven { url = uri("`+maven+`") }`)
+	default:
+		return data
+	}
+	return []byte(s)
+}
+
+// injectPomRepositories declares mirror as the first repository and plugin
+// repository of pom, adding the sections before </project> when missing.
+// The entries use the id central so that they override Maven Central from
+// the super POM instead of being tried after it.
+func injectPomRepositories(pom, mirror string) string {
+	var esc bytes.Buffer
+	xml.EscapeText(&esc, []byte(mirror))
+	if strings.Contains(pom, "<url>"+esc.String()+"</url>") {
+		return pom
+	}
+	for _, k := range []struct{ list, item string }{{"repositories", "repository"}, {"pluginRepositories", "pluginRepository"}} {
+		entry := fmt.Sprintf("\t\t<%s>\n\t\t\t<id>central</id>\n\t\t\t<url>%s</url>\n\t\t</%s>\n", k.item, esc.String(), k.item)
+		if i := strings.Index(pom, "<"+k.list+">"); i >= 0 {
+			i += len(k.list) + 2
+			if nl := strings.IndexByte(pom[i:], '\n'); nl >= 0 {
+				i += nl + 1
+			}
+			pom = pom[:i] + entry + pom[i:]
+			continue
+		}
+		i := strings.LastIndex(pom, "</project>")
+		if i < 0 {
+			return pom
+		}
+		// Keep the blank line Initializr leaves before </project>.
+		if strings.HasSuffix(pom[:i], "\n\n") {
+			i--
+		}
+		pom = pom[:i] + "\t<" + k.list + ">\n" + entry + "\t</" + k.list + ">\n" + pom[i:]
+	}
+	return pom
+}
+
+var gradleRepositories = regexp.MustCompile(`(?m)^repositories\s*\{[ \t]*\n`)
+
+// injectGradleRepository adds decl as the first entry of the top-level
+// repositories block of a build script, or in a new block at the end.
+func injectGradleRepository(script, decl string) string {
+	if strings.Contains(script, decl) {
+		return script
+	}
+	if loc := gradleRepositories.FindStringIndex(script); loc != nil {
+		return script[:loc[1]] + "\t" + decl + "\n" + script[loc[1]:]
+	}
+	if script != "" && !strings.HasSuffix(script, "\n") {
+		script += "\n"
+	}
+	return script + "\nrepositories {\n\t" + decl + "\n}\n"
+}
+
+// mirroredFiles are the project files rewrite may change.
+var mirroredFiles = []string{
+	".mvn/wrapper/maven-wrapper.properties", "gradle/wrapper/gradle-wrapper.properties",
+	"pom.xml", "build.gradle", "build.gradle.kts",
+}
+
+// applyMirrors rewrites the files of the project in dir for m and returns
+// the ones it changed.
+func applyMirrors(dir string, m mirrorConfig) ([]string, error) {
+	var changed []string
+	for _, name := range mirroredFiles {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		b, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return changed, err
+		}
+		out := m.rewrite(name, b)
+		if bytes.Equal(out, b) {
+			continue
+		}
+		fi, err := os.Stat(path)
+		if err != nil {
+			return changed, err
+		}
+		if err := os.WriteFile(path, out, fi.Mode().Perm()); err != nil {
+			return changed, err
+		}
+		changed = append(changed, name)
+	}
+	return changed, nil
+}
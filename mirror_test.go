@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mikoto2000/spring-initializr-cli/initializrtest"
+)
+
+const nexus = "https://nexus.example/repository/maven-public"
+
+func TestMirrorRewritesBuildFiles(t *testing.T) {
+	m := mirrorConfig{maven: nexus + "/", gradle: "https://nexus.example/repository/gradle-dist"}
+	cases := []struct{ path, in, want string }{
+		{".mvn/wrapper/maven-wrapper.properties",
+			"distributionUrl=https://repo.maven.apache.org/maven2/org/apache/maven/apache-maven/3.9.11/apache-maven-3.9.11-bin.zip\n",
+			"distributionUrl=" + nexus + "/org/apache/maven/apache-maven/3.9.11/apache-maven-3.9.11-bin.zip\n"},
+		{"gradle/wrapper/gradle-wrapper.properties",
+			"distributionUrl=https\\://services.gradle.org/distributions/gradle-8.14.3-bin.zip\n",
+			"distributionUrl=https\\://nexus.example/repository/gradle-dist/gradle-8.14.3-bin.zip\n"},
+		{"pom.xml",
+			"<project>\n\t</build>\n\n</project>\n",
+			"<project>\n\t</build>\n" +
+				"\t<repositories>\n\t\t<repository>\n\t\t\t<id>central</id>\n\t\t\t<url>" + nexus + "</url>\n\t\t</repository>\n\t</repositories>\n" +
+				"\t<pluginRepositories>\n\t\t<pluginRepository>\n\t\t\t<id>central</id>\n\t\t\t<url>" + nexus + "</url>\n\t\t</pluginRepository>\n\t</pluginRepositories>\n" +
+				"\n</project>\n"},
+		{"pom.xml",
+			"<project>\n\t<repositories>\n\t\t<repository>\n\t\t\t<id>spring-milestones</id>\n\t\t</repository>\n\t</repositories>\n\t<pluginRepositories>\n\t</pluginRepositories>\n</project>\n",
+			"<project>\n\t<repositories>\n\t\t<repository>\n\t\t\t<id>central</id>\n\t\t\t<url>" + nexus + "</url>\n\t\t</repository>\n\t\t<repository>\n\t\t\t<id>spring-milestones</id>\n\t\t</repository>\n\t</repositories>\n" +
+				"\t<pluginRepositories>\n\t\t<pluginRepository>\n\t\t\t<id>central</id>\n\t\t\t<url>" + nexus + "</url>\n\t\t</pluginRepository>\n\t</pluginRepositories>\n</project>\n"},
+		{"build.gradle",
+			"repositories {\n\tmavenCentral()\n}\n",
+			"repositories {\n\tmaven { url '" + nexus + "' }\n\tmavenCentral()\n}\n"},
+		{"build.gradle.kts",
+			"plugins {\n}\n",
+			"plugins {\n}\n\nrepositories {\n\tmaven { url = uri(\"" + nexus + "\") }\n}\n"},
+		{"HELP.md", "https://repo.maven.apache.org/maven2/\n", "https://repo.maven.apache.org/maven2/\n"},
+	}
+	for _, c := range cases {
+		got := string(m.rewrite(c.path, []byte(c.in)))
+		if got != c.want {
+			t.Errorf("%s:\n got %q\nwant %q", c.path, got, c.want)
+		}
+		if again := string(m.rewrite(c.path, []byte(got))); again != got {
+			t.Errorf("%s: second rewrite changed it:\n%q", c.path, again)
+		}
+	}
+	if pom := string(m.rewrite("pom.xml", []byte("<project>\n</project>\n"))); strings.Count(pom, "<id>central</id>") != 2 {
+		t.Errorf("central not overridden:\n%s", pom)
+	}
+	if err := (mirrorConfig{maven: "nexus.example"}).check(true); err == nil {
+		t.Error("mirror without a scheme accepted")
+	}
+	if err := m.check(false); err == nil || !strings.Contains(err.Error(), "require --extract") {
+		t.Errorf("mirror without --extract: err = %v", err)
+	}
+}
+
+func TestMirrorsAppliedOnExtract(t *testing.T) {
+	srv := initializrtest.NewServer()
+	defer srv.Close()
+	o := mockOptions(t, srv.URL)
+	o.Type = "gradle-project"
+	o.extract = true
+	o.mirrors = mirrorConfig{maven: nexus, gradle: "https://nexus.example/gradle"}
+	res, err := download(context.Background(), o, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(res.mirrored, ","); got != "gradle/wrapper/gradle-wrapper.properties,build.gradle" {
+		t.Fatalf("mirrored = %q", got)
+	}
+	wrapper, _ := os.ReadFile("demo/gradle/wrapper/gradle-wrapper.properties")
+	build, _ := os.ReadFile("demo/build.gradle")
+	if !strings.Contains(string(wrapper), `distributionUrl=https\://nexus.example/gradle/gradle-`) || strings.Contains(string(wrapper), "services.gradle.org") {
+		t.Errorf("wrapper:\n%s", wrapper)
+	}
+	if !strings.Contains(string(build), "repositories {\n\tmaven { url '"+nexus+"' }\n") {
+		t.Errorf("build.gradle:\n%s", build)
+	}
+	if changed, err := applyMirrors("demo", o.mirrors); err != nil || len(changed) != 0 {
+		t.Fatalf("second pass changed %v, %v", changed, err)
+	}
+}
+
+func TestUpgradeKeepsMirrors(t *testing.T) {
+	srv := initializrtest.NewServer()
+	defer srv.Close()
+	o := mockOptions(t, srv.URL)
+	o.BootVersion = "3.4.9"
+	o.extract = true
+	o.writeDescriptor = true
+	o.mirrors.maven = nexus
+	if _, err := download(context.Background(), o, nil); err != nil {
+		t.Fatal(err)
+	}
+	config := filepath.Join(t.TempDir(), "config.json")
+	os.WriteFile(config, []byte(`{"mavenMirror":"`+nexus+`"}`), 0o644)
+
+	var err error
+	captureStdout(t, func() {
+		err = runUpgrade(context.Background(), []string{"--config", config, "--to", "3.5.5", "--apply", "demo"})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrapper, _ := os.ReadFile("demo/.mvn/wrapper/maven-wrapper.properties")
+	if !strings.Contains(string(wrapper), nexus+"/org/apache/maven/apache-maven/3.9.11/") {
+		t.Fatalf("wrapper:\n%s", wrapper)
+	}
+	pom, _ := os.ReadFile("demo/pom.xml")
+	if strings.Count(string(pom), "<url>"+nexus+"</url>") != 2 {
+		t.Fatalf("pom.xml:\n%s", pom)
+	}
+}
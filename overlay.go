@@ -0,0 +1,238 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/mikoto2000/spring-initializr-cli/initializr"
+)
+
+// overlaySuffix marks overlay files rendered with text/template; the suffix
+// is dropped from the copy. Other files are copied as they are, so files
+// with their own {{ }} syntax, like CI workflows, need no escaping.
+const overlaySuffix = ".tmpl"
+
+// Overlay conflict policies, for overlay files that the generated project
+// already has.
+const (
+	overlayFail      = "fail"
+	overlaySkip      = "skip"
+	overlayOverwrite = "overwrite"
+)
+
+// overlayConfig lists the template trees copied onto an extracted project.
+type overlayConfig struct {
+	// configured come from the config file and preset, and are applied
+	// before dirs, the --overlay flags. Only dirs belong on a command line.
+	configured []string
+	dirs       overlayList
+	conflict   string // overlayFail (default), overlaySkip or overlayOverwrite
+}
+
+// all returns every overlay directory in the order they are applied.
+func (c overlayConfig) all() []string {
+	return append(slices.Clone(c.configured), c.dirs...)
+}
+
+// overlayList is the repeatable --overlay flag.
+type overlayList []string
+
+func (l *overlayList) String() string { return strings.Join(*l, ",") }
+
+func (l *overlayList) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+// repeated lets commandLine render one --overlay per directory.
+func (l *overlayList) repeated() []string { return *l }
+
+// registerOverlayFlags adds the overlay flags to fs.
+func registerOverlayFlags(fs *flag.FlagSet, c *overlayConfig) {
+	fs.Var(&c.dirs, "overlay", "With --extract, copy this template directory onto the project (repeatable; later ones win)")
+	fs.StringVar(&c.conflict, "overlay-conflict", "", "When an overlay file exists in the generated project: fail, skip or overwrite (default: fail)")
+}
+
+// check reports a misuse of the overlay flags before anything is generated.
+func (c overlayConfig) check(extract bool) error {
+	switch c.conflict {
+	case "", overlayFail, overlaySkip, overlayOverwrite:
+	default:
+		return fmt.Errorf("invalid --overlay-conflict %q: want fail, skip or overwrite", c.conflict)
+	}
+	if len(c.all()) > 0 && !extract {
+		return errors.New("--overlay requires --extract")
+	}
+	return nil
+}
+
+// overlayData returns the template variables for r, named like the fields
+// of a descriptor's request, plus packagePath: the package as a directory.
+func overlayData(r initializr.ProjectRequest) map[string]string {
+	return map[string]string{
+		"type":                    r.Type,
+		"language":                r.Language,
+		"bootVersion":             r.BootVersion,
+		"groupId":                 r.GroupID,
+		"artifactId":              r.ArtifactID,
+		"name":                    r.Name,
+		"description":             r.Description,
+		"packageName":             r.PackageName,
+		"packagePath":             strings.ReplaceAll(r.PackageName, ".", "/"),
+		"packaging":               r.Packaging,
+		"javaVersion":             r.JavaVersion,
+		"configurationFileFormat": r.ConfigurationFileFormat,
+		"dependencies":            strings.Join(r.Dependencies, ","),
+	}
+}
+
+// render executes text as a template named name over data. Unknown
+// variables are errors rather than empty strings.
+func render(name, text string, data map[string]string) (string, error) {
+	t, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// readOverlay renders the files of the template tree dir, keyed by their
+// slash-separated path in the project. Path segments may use the template
+// variables too, e.g. src/main/java/{{.packagePath}}/config/.
+func readOverlay(dir string, data map[string]string) (map[string]initializr.File, error) {
+	files := map[string]initializr.File{}
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		fi, err := os.Stat(p)
+		if err != nil {
+			return err
+		}
+		if !fi.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		if strings.Contains(name, "{{") {
+			if name, err = render(rel, name, data); err != nil {
+				return err
+			}
+		}
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		if strings.HasSuffix(name, overlaySuffix) {
+			name = strings.TrimSuffix(name, overlaySuffix)
+			s, err := render(rel, string(b), data)
+			if err != nil {
+				return err
+			}
+			b = []byte(s)
+		}
+		name = path.Clean(name)
+		if !filepath.IsLocal(filepath.FromSlash(name)) {
+			return fmt.Errorf("%s: renders to %q, outside the project", rel, name)
+		}
+		files[name] = initializr.File{Path: name, Mode: fi.Mode().Perm(), Data: b}
+		return nil
+	})
+	return files, err
+}
+
+// overlayPlan is the rendered overlay files for a project, and those of
+// them the project has as well.
+type overlayPlan struct {
+	files     map[string]initializr.File
+	conflicts []string
+}
+
+// planOverlays renders the overlays of c for r, later overlays replacing
+// the files of earlier ones. A file in generated, the paths the project is
+// about to get, or already in dir is a conflict; with overlayFail that is
+// an error, reported before the project is written.
+func planOverlays(dir string, c overlayConfig, r initializr.ProjectRequest, generated []string) (overlayPlan, error) {
+	plan := overlayPlan{files: map[string]initializr.File{}}
+	data := overlayData(r)
+	for _, d := range c.all() {
+		fi, err := os.Stat(d)
+		if err != nil {
+			return plan, fmt.Errorf("overlay: %w", err)
+		}
+		if !fi.IsDir() {
+			return plan, fmt.Errorf("overlay: %s is not a directory", d)
+		}
+		tree, err := readOverlay(d, data)
+		if err != nil {
+			return plan, fmt.Errorf("overlay %s: %w", d, err)
+		}
+		maps.Copy(plan.files, tree)
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(plan.files)) {
+		if slices.Contains(generated, name) {
+			plan.conflicts = append(plan.conflicts, name)
+		} else if _, err := os.Lstat(filepath.Join(dir, filepath.FromSlash(name))); err == nil {
+			plan.conflicts = append(plan.conflicts, name)
+		}
+	}
+	if len(plan.conflicts) > 0 && (c.conflict == "" || c.conflict == overlayFail) {
+		return plan, fmt.Errorf("overlay: the generated project already has %s; choose --overlay-conflict skip or overwrite", strings.Join(plan.conflicts, ", "))
+	}
+	return plan, nil
+}
+
+// overlayResult counts what applyOverlays did.
+type overlayResult struct {
+	written int
+	skipped []string // files the project already had, under overlaySkip
+}
+
+// applyOverlays writes the files of plan onto the project in dir, handling
+// its conflicts by c.conflict.
+func applyOverlays(dir string, c overlayConfig, plan overlayPlan) (overlayResult, error) {
+	var res overlayResult
+	for _, name := range slices.Sorted(maps.Keys(plan.files)) {
+		if c.conflict == overlaySkip && slices.Contains(plan.conflicts, name) {
+			res.skipped = append(res.skipped, name)
+			continue
+		}
+		f := plan.files[name]
+		dst := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return res, err
+		}
+		if err := os.WriteFile(dst, f.Data, f.Mode); err != nil {
+			return res, err
+		}
+		// WriteFile keeps the mode of a file it overwrites.
+		if err := os.Chmod(dst, f.Mode); err != nil {
+			return res, err
+		}
+		res.written++
+	}
+	return res, nil
+}
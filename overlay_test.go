@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mikoto2000/spring-initializr-cli/initializrtest"
+)
+
+func TestOverlayRendersTemplates(t *testing.T) {
+	srv := initializrtest.NewServer()
+	defer srv.Close()
+	org, team := t.TempDir(), t.TempDir()
+	writeTree(t, org, map[string]string{
+		".github/workflows/ci.yml": "image: app:${{ github.sha }}\n",
+		"Dockerfile.tmpl":          "FROM eclipse-temurin:{{.javaVersion}}\n",
+		"src/main/java/{{.packagePath}}/config/LoggingConfig.java.tmpl": "package {{.packageName}}.config;\n",
+		".git/HEAD": "ref: refs/heads/main\n",
+	})
+	writeTree(t, team, map[string]string{
+		"Dockerfile.tmpl": "FROM eclipse-temurin:{{.javaVersion}}-alpine\nCOPY target/{{.artifactId}}.jar app.jar\n",
+	})
+	os.Chmod(filepath.Join(org, ".github/workflows/ci.yml"), 0o600)
+
+	o := mockOptions(t, srv.URL)
+	o.extract = true
+	o.overlay.dirs = overlayList{org, team}
+	res, err := download(context.Background(), o, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.overlaid != 3 || !strings.HasSuffix(res.summary(), ", 3 overlay files") {
+		t.Fatalf("result = %+v", res)
+	}
+	for name, want := range map[string]string{
+		"demo/.github/workflows/ci.yml": "image: app:${{ github.sha }}\n",
+		// The server's default Java version fills the empty option.
+		"demo/Dockerfile": "FROM eclipse-temurin:17-alpine\nCOPY target/demo.jar app.jar\n",
+		"demo/src/main/java/com/example/demo/config/LoggingConfig.java": "package com.example.demo.config;\n",
+	} {
+		if b, err := os.ReadFile(name); err != nil || string(b) != want {
+			t.Errorf("%s = %q, %v; want %q", name, b, err, want)
+		}
+	}
+	if fi, err := os.Stat("demo/.github/workflows/ci.yml"); err != nil || fi.Mode().Perm() != 0o600 {
+		t.Errorf("mode not kept: %v %v", fi.Mode(), err)
+	}
+	if _, err := os.Stat("demo/.git"); err == nil {
+		t.Error("overlay's .git copied")
+	}
+}
+
+func TestOverlayConflictPolicy(t *testing.T) {
+	srv := initializrtest.NewServer()
+	defer srv.Close()
+	overlay := t.TempDir()
+	writeTree(t, overlay, map[string]string{"HELP.md": "# Team help\n", "CODEOWNERS": "* @acme/platform\n"})
+	o := mockOptions(t, srv.URL)
+	o.extract = true
+	o.overlay.dirs = overlayList{overlay}
+
+	if _, err := download(context.Background(), o, nil); err == nil || !strings.Contains(err.Error(), "already has HELP.md") {
+		t.Fatalf("err = %v", err)
+	}
+	if _, err := os.Stat("demo"); err == nil {
+		t.Fatal("project extracted despite the conflict")
+	}
+
+	for _, c := range []struct{ policy, help string }{{overlaySkip, "# Getting Started"}, {overlayOverwrite, "# Team help"}} {
+		os.RemoveAll("demo")
+		o.overlay.conflict = c.policy
+		res, err := download(context.Background(), o, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		help, _ := os.ReadFile("demo/HELP.md")
+		owners, _ := os.ReadFile("demo/CODEOWNERS")
+		if !strings.HasPrefix(string(help), c.help) || string(owners) != "* @acme/platform\n" {
+			t.Errorf("%s: HELP.md = %q, CODEOWNERS = %q", c.policy, help, owners)
+		}
+		if skipped := strings.Join(res.overlaySkipped, ","); (c.policy == overlaySkip) != (skipped == "HELP.md") {
+			t.Errorf("%s: skipped = %q", c.policy, skipped)
+		}
+	}
+}
+
+func TestOverlaysFromPreset(t *testing.T) {
+	dir := t.TempDir()
+	preset := filepath.Join(dir, "team.json")
+	os.WriteFile(preset, []byte(`{"overlays":["templates/service"],"overlayConflict":"skip"}`), 0o644)
+
+	var o options
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	extra := registerFlags(fs, &o)
+	if err := fs.Parse([]string{"--config", "", "--preset", preset, "--overlay", "local", "--overlay", "more templates"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyConfig(fs, *extra.configPath, &o); err != nil {
+		t.Fatal(err)
+	}
+	want := filepath.Join(dir, "templates", "service") + ",local,more templates"
+	if got := strings.Join(o.overlay.all(), ","); got != want || o.overlay.conflict != overlaySkip {
+		t.Fatalf("overlays = %q, conflict = %q", got, o.overlay.conflict)
+	}
+	// The config's overlays are not repeated on the command line, where
+	// they would be applied a second time.
+	if cl := commandLine(o); !strings.Contains(cl, " --overlay local --overlay 'more templates' ") || strings.Contains(cl, "templates/service") {
+		t.Fatalf("command line = %q", cl)
+	}
+	if err := o.overlay.check(false); err == nil {
+		t.Fatal("--overlay accepted without --extract")
+	}
+}
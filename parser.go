@@ -6,6 +6,8 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/mikoto2000/spring-initializr-cli/initializr"
 )
 
 func parseFlags() options {
@@ -14,33 +16,7 @@ func parseFlags() options {
 	// This is applied after flag.Parse so explicit flags still override.
 	noArgs := len(os.Args) == 1
 
-	flag.StringVar(&o.baseURL, "base-url", defaultBaseURL, "Spring Initializr base URL")
-	flag.StringVar(&o.target, "target", "zip", "Archive format: zip (default)")
-	flag.StringVar(&o.projectType, "type", "maven-project", "Project type: maven-project, gradle-project, or gradle-build")
-	flag.StringVar(&o.language, "language", "java", "Language: java, kotlin, or groovy")
-	flag.StringVar(&o.bootVersion, "boot-version", "", "Spring Boot version (optional)")
-	flag.StringVar(&o.groupID, "group-id", "com.example", "Group ID")
-	flag.StringVar(&o.artifactID, "artifact-id", "demo", "Artifact ID")
-	flag.StringVar(&o.name, "name", "demo", "Project name")
-	flag.StringVar(&o.description, "description", "Demo project for Spring Boot", "Project description")
-	flag.StringVar(&o.packageName, "package-name", "", "Base package name (default: groupId + '.' + artifactId)")
-	flag.StringVar(&o.packaging, "packaging", "jar", "Packaging: jar or war")
-	flag.StringVar(&o.javaVersion, "java-version", "", "Java version (optional). If omitted, server default is used")
-	flag.StringVar(&o.configFileFormat, "configuration-file-format", "", "Configuration file format: properties or yaml (optional)")
-	flag.StringVar(&o.dependencies, "dependencies", "", "Comma-separated dependency IDs, e.g. web,data-jpa,postgresql")
-	flag.StringVar(&o.baseDir, "base-dir", "", "Project root directory name (default: artifactId)")
-
-	flag.StringVar(&o.output, "output", "", "Output zip file path (default: <artifactId>.zip)")
-	flag.BoolVar(&o.extract, "extract", false, "Extract archive into directory (uses base-dir)")
-	flag.BoolVar(&o.dryRun, "dry-run", false, "Print the generated URL and exit")
-	flag.IntVar(&o.timeout, "timeout", 60, "Download timeout in seconds")
-	flag.BoolVar(&o.verbose, "v", false, "Verbose output")
-	flag.BoolVar(&o.interactive, "interactive", false, "Interactive TUI mode")
-	flag.BoolVar(&o.interactive, "i", false, "Interactive TUI mode (shorthand)")
-	flag.BoolVar(&o.showVersion, "version", false, "Print version and exit")
-	flag.BoolVar(&o.showVersion, "V", false, "Print version and exit (shorthand)")
-	flag.BoolVar(&o.showLicense, "license", false, "Print licenses (app + NOTICE) and exit")
-	flag.BoolVar(&o.showLicense, "L", false, "Print licenses (app + NOTICE) and exit (shorthand)")
+	extra := registerFlags(flag.CommandLine, &o)
 
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Spring Initializr CLI (Go)\n\n")
@@ -53,40 +29,138 @@ func parseFlags() options {
 		fmt.Fprintf(os.Stderr, "\nNotes:\n- Dependencies are Spring Initializr IDs (e.g. web, data-jpa, security).\n")
 		fmt.Fprintf(os.Stderr, "- If --extract is set, the zip will be downloaded and extracted into --base-dir (defaults to artifact-id).\n")
 		fmt.Fprintf(os.Stderr, "- Use --dry-run to just print the URL.\n")
+		fmt.Fprintf(os.Stderr, "- Use --from-url to start from a link shared from start.spring.io, --share-url to make one.\n")
 		fmt.Fprintf(os.Stderr, "- Use --version or -V to print the version.\n")
 		fmt.Fprintf(os.Stderr, "- Use --license or -L to print licenses and exit.\n")
 	}
 
 	flag.Parse()
+	flag.Visit(func(f *flag.Flag) { o.outputSet = o.outputSet || f.Name == "output" })
+
+	if err := extra.apply(flag.CommandLine, &o); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(2)
+	}
 
 	if noArgs {
 		o.interactive = true
 	}
 
-	// Fill derived defaults
-	if o.baseDir == "" {
-		o.baseDir = o.artifactID
+	o.fillDefaults()
+	return o
+}
+
+// fillDefaults fills the values derived from other options and normalizes
+// the project type shortcuts.
+func (o *options) fillDefaults() {
+	if o.BaseDir == "" {
+		o.BaseDir = o.ArtifactID
 	}
-	if o.packageName == "" {
-		o.packageName = sanitizePackage(o.groupID + "." + o.artifactID)
+	if o.PackageName == "" {
+		o.PackageName = initializr.SanitizePackage(o.GroupID + "." + o.ArtifactID)
 	} else {
-		o.packageName = sanitizePackage(o.packageName)
+		o.PackageName = initializr.SanitizePackage(o.PackageName)
 	}
 	if o.output == "" {
-		o.output = o.artifactID + ".zip"
+		o.output = o.ArtifactID + ".zip"
 	}
 
 	// Normalize some shortcuts
-	switch strings.ToLower(o.projectType) {
+	switch strings.ToLower(o.Type) {
 	case "maven", "maven-project":
-		o.projectType = "maven-project"
+		o.Type = "maven-project"
 	case "gradle", "gradle-project":
-		o.projectType = "gradle-project"
+		o.Type = "gradle-project"
 	case "gradle-build":
 		// as-is
 	default:
 		// keep as provided, server will validate
 	}
+}
 
-	return o
+// extraFlags are the flags that do not map onto an options field.
+type extraFlags struct {
+	configPath     *string
+	fromURL        *string
+	fromDescriptor *string
+}
+
+// apply layers the config file, preset, descriptor and share link named by
+// the flags of fs onto o. Flags set on the command line keep their values.
+func (extra extraFlags) apply(fs *flag.FlagSet, o *options) error {
+	if err := applyConfig(fs, *extra.configPath, o); err != nil {
+		return err
+	}
+	if *extra.fromDescriptor != "" {
+		if err := applyDescriptor(fs, *extra.fromDescriptor, o, o.extract); err != nil {
+			return err
+		}
+	}
+	if *extra.fromURL != "" {
+		if err := applySharedURL(fs, *extra.fromURL, o); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// registerFlags defines the command-line flags on fs, bound to o.
+func registerFlags(fs *flag.FlagSet, o *options) extraFlags {
+	var extra extraFlags
+	fs.StringVar(&o.baseURL, "base-url", defaultBaseURL, "Spring Initializr base URL")
+	fs.StringVar(&o.target, "target", "zip", "Archive format: zip (default)")
+	registerProjectFlags(fs, o)
+
+	fs.StringVar(&o.output, "output", "", "Output zip file path (default: <artifactId>.zip)")
+	fs.BoolVar(&o.extract, "extract", false, "Extract archive into directory (uses base-dir)")
+	fs.BoolVar(&o.dryRun, "dry-run", false, "Print the generated URL and exit")
+	fs.BoolVar(&o.printShareURL, "share-url", false, "Print a start.spring.io web UI link for these options and exit")
+	extra.fromURL = fs.String("from-url", "", "Take settings from a share link (https://start.spring.io/#!...) or a /starter.zip?... URL; flags override it")
+	fs.BoolVar(&o.writeDescriptor, "write-descriptor", false, "With --extract, record the request in <base-dir>/"+descriptorName)
+	extra.fromDescriptor = fs.String("from-descriptor", "", "Regenerate the request recorded in a "+descriptorName+" file (or project directory); flags override it")
+	registerMirrorFlags(fs, &o.mirrors)
+	registerOverlayFlags(fs, &o.overlay)
+	registerGitFlags(fs, &o.git)
+	fs.IntVar(&o.timeout, "timeout", 60, "Download timeout in seconds")
+	fs.BoolVar(&o.verbose, "v", false, "Verbose output")
+	fs.BoolVar(&o.debugHTTP, "debug-http", false, "Log HTTP requests and responses (secrets redacted) to stderr")
+	fs.BoolVar(&o.interactive, "interactive", false, "Interactive TUI mode")
+	fs.BoolVar(&o.interactive, "i", false, "Interactive TUI mode (shorthand)")
+	fs.BoolVar(&o.showVersion, "version", false, "Print version and exit")
+	fs.BoolVar(&o.showVersion, "V", false, "Print version and exit (shorthand)")
+	fs.BoolVar(&o.showLicense, "license", false, "Print licenses (app + NOTICE) and exit")
+	fs.BoolVar(&o.showLicense, "L", false, "Print licenses (app + NOTICE) and exit (shorthand)")
+	extra.configPath = fs.String("config", defaultConfigPath(), "JSON config file (flags override its values)")
+	fs.String("preset", "", "JSON preset in the config file format, layered over --config (flags override it)")
+	registerTransportFlags(fs, &o.transport)
+	registerAuthFlags(fs, &o.auth)
+	return extra
+}
+
+// registerProjectFlags defines the flags for the project settings sent to
+// the server, bound to o.ProjectRequest.
+func registerProjectFlags(fs *flag.FlagSet, o *options) {
+	fs.StringVar(&o.Type, "type", "maven-project", "Project type: maven-project, gradle-project, or gradle-build")
+	fs.StringVar(&o.Language, "language", "java", "Language: java, kotlin, or groovy")
+	fs.StringVar(&o.BootVersion, "boot-version", "", "Spring Boot version (optional)")
+	fs.StringVar(&o.GroupID, "group-id", "com.example", "Group ID")
+	fs.StringVar(&o.ArtifactID, "artifact-id", "demo", "Artifact ID")
+	fs.StringVar(&o.Name, "name", "demo", "Project name")
+	fs.StringVar(&o.Description, "description", "Demo project for Spring Boot", "Project description")
+	fs.StringVar(&o.PackageName, "package-name", "", "Base package name (default: groupId + '.' + artifactId)")
+	fs.StringVar(&o.Packaging, "packaging", "jar", "Packaging: jar or war")
+	fs.StringVar(&o.JavaVersion, "java-version", "", "Java version (optional). If omitted, server default is used")
+	fs.StringVar(&o.ConfigurationFileFormat, "configuration-file-format", "", "Configuration file format: properties or yaml (optional)")
+	fs.Var((*dependencyList)(&o.Dependencies), "dependencies", "Comma-separated dependency IDs, e.g. web,data-jpa,postgresql")
+	fs.StringVar(&o.BaseDir, "base-dir", "", "Project root directory name (default: artifactId)")
+}
+
+// dependencyList is a flag.Value for comma-separated dependency IDs.
+type dependencyList []string
+
+func (d *dependencyList) String() string { return strings.Join(*d, ",") }
+
+func (d *dependencyList) Set(s string) error {
+	*d = initializr.ParseDependencies(s)
+	return nil
 }
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// progressFunc is called as archive bytes arrive. total is -1 when the server
+// did not send a Content-Length.
+type progressFunc func(received, total int64)
+
+// progressReader wraps a reader and reports the running byte count.
+type progressReader struct {
+	r        io.Reader
+	received int64
+	total    int64
+	fn       progressFunc
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.received += int64(n)
+	if p.fn != nil && n > 0 {
+		p.fn(p.received, p.total)
+	}
+	return n, err
+}
+
+// throttleProgress limits calls to fn to one per interval, except for the
+// final update when the expected total has been reached.
+func throttleProgress(fn progressFunc, interval time.Duration) progressFunc {
+	var last time.Time
+	return func(received, total int64) {
+		now := time.Now()
+		if now.Sub(last) < interval && (total < 0 || received < total) {
+			return
+		}
+		last = now
+		fn(received, total)
+	}
+}
+
+// progressPrinter renders a single, self-overwriting progress line.
+type progressPrinter struct {
+	w       io.Writer
+	start   time.Time
+	printed bool
+}
+
+// newStderrProgress returns a progress callback and a finish func for stderr.
+// Both are no-ops when stderr is not a terminal.
+func newStderrProgress() (progressFunc, func()) {
+	if !term.IsTerminal(int(os.Stderr.Fd())) {
+		return nil, func() {}
+	}
+	p := &progressPrinter{w: os.Stderr, start: time.Now()}
+	return throttleProgress(p.update, 100*time.Millisecond), p.finish
+}
+
+func (p *progressPrinter) update(received, total int64) {
+	line := formatProgress(received, total, time.Since(p.start))
+	// Pad to clear leftovers from a previously longer line.
+	fmt.Fprintf(p.w, "\r%-60s", line)
+	p.printed = true
+}
+
+func (p *progressPrinter) finish() {
+	if p.printed {
+		fmt.Fprintln(p.w)
+	}
+}
+
+// formatProgress renders e.g. "1.2 MiB received, 300.0 KiB/s, 45%".
+// The percentage is omitted when total is unknown.
+func formatProgress(received, total int64, elapsed time.Duration) string {
+	var b strings.Builder
+	b.WriteString(formatBytes(received))
+	b.WriteString(" received")
+	if secs := elapsed.Seconds(); secs > 0 {
+		b.WriteString(", ")
+		b.WriteString(formatBytes(int64(float64(received) / secs)))
+		b.WriteString("/s")
+	}
+	if total > 0 {
+		fmt.Fprintf(&b, ", %d%%", received*100/total)
+	}
+	return b.String()
+}
+
+// formatBytes renders a byte count with binary units.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
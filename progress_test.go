@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatBytes(t *testing.T) {
+	cases := []struct {
+		in  int64
+		out string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KiB"},
+		{1536, "1.5 KiB"},
+		{5 * 1024 * 1024, "5.0 MiB"},
+	}
+	for _, c := range cases {
+		if got := formatBytes(c.in); got != c.out {
+			t.Errorf("formatBytes(%d) = %q; want %q", c.in, got, c.out)
+		}
+	}
+}
+
+func TestFormatProgress(t *testing.T) {
+	got := formatProgress(512*1024, 1024*1024, 2*time.Second)
+	if got != "512.0 KiB received, 256.0 KiB/s, 50%" {
+		t.Fatalf("formatProgress with total = %q", got)
+	}
+	got = formatProgress(2048, -1, time.Second)
+	if got != "2.0 KiB received, 2.0 KiB/s" {
+		t.Fatalf("formatProgress without total = %q", got)
+	}
+}
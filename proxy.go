@@ -0,0 +1,269 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// archivePaths are the endpoints whose responses are generated projects.
+// Everything else under the upstream is treated as metadata.
+var archivePaths = map[string]bool{
+	"/starter.zip":  true,
+	"/starter.tgz":  true,
+	"/pom.xml":      true,
+	"/build.gradle": true,
+}
+
+// cachedResponse is an upstream response kept by the proxy.
+type cachedResponse struct {
+	status  int
+	header  http.Header
+	body    []byte
+	fetched time.Time
+}
+
+// initializrProxy forwards Initializr API calls to an upstream server and
+// caches successful responses. Metadata expires after metaTTL and archives
+// after archiveTTL; expired entries are still served when the upstream
+// cannot be reached.
+type initializrProxy struct {
+	upstream   *url.URL
+	client     *http.Client
+	metaTTL    time.Duration
+	archiveTTL time.Duration
+	maxEntries int // per kind; the oldest entry is evicted beyond this
+	logf       func(format string, args ...any)
+	now        func() time.Time
+
+	mu       sync.Mutex
+	meta     map[string]*cachedResponse
+	archives map[string]*cachedResponse
+}
+
+func newInitializrProxy(upstream string, timeout time.Duration) (*initializrProxy, error) {
+	u, err := url.Parse(strings.TrimRight(upstream, "/"))
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("invalid upstream URL %q", upstream)
+	}
+	return &initializrProxy{
+		upstream:   u,
+		client:     &http.Client{Timeout: timeout},
+		metaTTL:    10 * time.Minute,
+		archiveTTL: 24 * time.Hour,
+		maxEntries: 256,
+		logf:       func(string, ...any) {},
+		now:        time.Now,
+		meta:       map[string]*cachedResponse{},
+		archives:   map[string]*cachedResponse{},
+	}, nil
+}
+
+func (p *initializrProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	isArchive := archivePaths[r.URL.Path]
+	cache, ttl := p.meta, p.metaTTL
+	key := r.URL.Path + "?" + normalizeQuery(r.URL.Query()) + "|" + r.Header.Get("Accept")
+	if isArchive {
+		cache, ttl = p.archives, p.archiveTTL
+		// Archives do not depend on Accept.
+		key = r.URL.Path + "?" + normalizeQuery(r.URL.Query())
+	}
+
+	p.mu.Lock()
+	entry := cache[key]
+	p.mu.Unlock()
+
+	state := "HIT"
+	if entry == nil || p.now().Sub(entry.fetched) >= ttl {
+		fresh, err := p.fetch(r)
+		switch {
+		case err == nil && fresh.status >= 200 && fresh.status < 300:
+			p.store(cache, key, fresh)
+			entry, state = fresh, "MISS"
+		case err == nil && fresh.status < 500:
+			// Client errors (e.g. an unknown dependency) are passed through
+			// uncached; serving a stale success would hide them.
+			p.logf("%s %s -> %d", r.Method, r.URL.RequestURI(), fresh.status)
+			p.write(w, r, fresh, "BYPASS", isArchive)
+			return
+		case entry != nil:
+			if err == nil {
+				err = fmt.Errorf("upstream returned %d", fresh.status)
+			}
+			p.logf("%s %s: %v; serving stale copy from %s", r.Method, r.URL.RequestURI(), err, entry.fetched.Format(time.RFC3339))
+			state = "STALE"
+		case err == nil:
+			p.write(w, r, fresh, "MISS", isArchive)
+			return
+		default:
+			p.logf("%s %s: %v", r.Method, r.URL.RequestURI(), err)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadGateway)
+			json.NewEncoder(w).Encode(map[string]any{
+				"status":  http.StatusBadGateway,
+				"error":   http.StatusText(http.StatusBadGateway),
+				"message": "upstream unavailable and nothing cached: " + err.Error(),
+				"path":    r.URL.Path,
+			})
+			return
+		}
+	}
+	p.logf("%s %s -> %s", r.Method, r.URL.RequestURI(), state)
+	p.write(w, r, entry, state, isArchive)
+}
+
+// fetch performs r against the upstream and reads the whole response.
+func (p *initializrProxy) fetch(r *http.Request) (*cachedResponse, error) {
+	u := *p.upstream
+	u.Path = strings.TrimRight(p.upstream.Path, "/") + r.URL.Path
+	u.RawQuery = r.URL.RawQuery
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range []string{"Accept", "User-Agent"} {
+		if v := r.Header.Get(h); v != "" {
+			req.Header.Set(h, v)
+		}
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	header := http.Header{}
+	for _, h := range []string{"Content-Type", "Content-Disposition"} {
+		if v := resp.Header.Get(h); v != "" {
+			header.Set(h, v)
+		}
+	}
+	return &cachedResponse{status: resp.StatusCode, header: header, body: body, fetched: p.now()}, nil
+}
+
+// store adds e to cache, evicting the oldest entry when the cache is full.
+func (p *initializrProxy) store(cache map[string]*cachedResponse, key string, e *cachedResponse) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	cache[key] = e
+	for len(cache) > p.maxEntries {
+		oldest := ""
+		for k, v := range cache {
+			if oldest == "" || v.fetched.Before(cache[oldest].fetched) {
+				oldest = k
+			}
+		}
+		delete(cache, oldest)
+	}
+}
+
+// write sends e to the client. Metadata links that point at the upstream are
+// rewritten to the proxy so follow-up requests are cached too.
+func (p *initializrProxy) write(w http.ResponseWriter, r *http.Request, e *cachedResponse, state string, isArchive bool) {
+	body := e.body
+	if !isArchive {
+		body = bytes.ReplaceAll(body, []byte(p.upstreamOrigin()), []byte(requestOrigin(r)))
+	}
+	for k, v := range e.header {
+		w.Header()[k] = v
+	}
+	w.Header().Set("X-Cache", state)
+	if state == "STALE" {
+		w.Header().Set("Warning", `110 - "Response is Stale"`)
+	}
+	w.Header().Set("Content-Length", fmt.Sprint(len(body)))
+	w.WriteHeader(e.status)
+	if r.Method != http.MethodHead {
+		w.Write(body)
+	}
+}
+
+func (p *initializrProxy) upstreamOrigin() string {
+	return strings.TrimRight(p.upstream.String(), "/")
+}
+
+func requestOrigin(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+// normalizeQuery renders q with sorted keys, trimmed values and sorted,
+// de-duplicated dependency IDs, so equivalent requests share a cache entry.
+func normalizeQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		var vals []string
+		for _, v := range q[k] {
+			if k == "dependencies" {
+				for _, id := range strings.Split(v, ",") {
+					if id = strings.TrimSpace(id); id != "" {
+						vals = append(vals, id)
+					}
+				}
+				continue
+			}
+			if v = strings.TrimSpace(v); v != "" {
+				vals = append(vals, v)
+			}
+		}
+		if len(vals) == 0 {
+			continue
+		}
+		if k == "dependencies" {
+			sort.Strings(vals)
+			vals = dedupSorted(vals)
+			vals = []string{strings.Join(vals, ",")}
+		}
+		for _, v := range vals {
+			parts = append(parts, url.QueryEscape(k)+"="+url.QueryEscape(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func dedupSorted(s []string) []string {
+	out := s[:0]
+	for i, v := range s {
+		if i == 0 || v != s[i-1] {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// newProxyLogger returns a logf that writes to w when verbose is set.
+func newProxyLogger(w io.Writer, verbose bool) func(string, ...any) {
+	if !verbose {
+		return func(string, ...any) {}
+	}
+	l := log.New(w, "proxy: ", log.LstdFlags)
+	return l.Printf
+}
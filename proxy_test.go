@@ -0,0 +1,146 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mikoto2000/spring-initializr-cli/initializrtest"
+)
+
+// flakyUpstream wraps the mock Initializr, counting requests and failing
+// them on demand.
+type flakyUpstream struct {
+	calls atomic.Int32
+	down  atomic.Bool
+	next  http.Handler
+}
+
+func (u *flakyUpstream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	u.calls.Add(1)
+	if u.down.Load() {
+		http.Error(w, "upstream down", http.StatusServiceUnavailable)
+		return
+	}
+	u.next.ServeHTTP(w, r)
+}
+
+func startProxy(t *testing.T) (*flakyUpstream, *initializrProxy, *httptest.Server, *time.Time) {
+	t.Helper()
+	up := &flakyUpstream{next: initializrtest.NewHandler()}
+	upSrv := httptest.NewServer(up)
+	t.Cleanup(upSrv.Close)
+	p, err := newInitializrProxy(upSrv.URL, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	p.now = func() time.Time { return now }
+	srv := httptest.NewServer(p)
+	t.Cleanup(srv.Close)
+	return up, p, srv, &now
+}
+
+func proxyGet(t *testing.T, u string) (*http.Response, string) {
+	t.Helper()
+	resp, err := http.Get(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	return resp, string(b)
+}
+
+func TestProxyCachesArchivesByNormalizedQuery(t *testing.T) {
+	up, _, srv, _ := startProxy(t)
+	resp, first := proxyGet(t, srv.URL+"/starter.zip?dependencies=web,security&type=maven-project")
+	if resp.Header.Get("X-Cache") != "MISS" {
+		t.Fatalf("first request X-Cache = %q", resp.Header.Get("X-Cache"))
+	}
+	resp, second := proxyGet(t, srv.URL+"/starter.zip?type=maven-project&dependencies=security,+web&name=")
+	if resp.Header.Get("X-Cache") != "HIT" || second != first {
+		t.Fatalf("equivalent request not served from cache: X-Cache=%q", resp.Header.Get("X-Cache"))
+	}
+	if n := up.calls.Load(); n != 1 {
+		t.Fatalf("upstream calls = %d; want 1", n)
+	}
+}
+
+func TestProxyMetadataTTLAndStale(t *testing.T) {
+	up, _, srv, now := startProxy(t)
+	proxyGet(t, srv.URL+"/metadata/client")
+
+	*now = now.Add(5 * time.Minute)
+	if resp, _ := proxyGet(t, srv.URL+"/metadata/client"); resp.Header.Get("X-Cache") != "HIT" {
+		t.Fatalf("fresh metadata X-Cache = %q", resp.Header.Get("X-Cache"))
+	}
+
+	*now = now.Add(10 * time.Minute)
+	up.down.Store(true)
+	resp, body := proxyGet(t, srv.URL+"/metadata/client")
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("X-Cache") != "STALE" || !strings.Contains(body, "bootVersion") {
+		t.Fatalf("expired metadata with upstream down: status=%d X-Cache=%q", resp.StatusCode, resp.Header.Get("X-Cache"))
+	}
+
+	up.down.Store(false)
+	if resp, _ := proxyGet(t, srv.URL+"/metadata/client"); resp.Header.Get("X-Cache") != "MISS" {
+		t.Fatalf("expired metadata with upstream back X-Cache = %q", resp.Header.Get("X-Cache"))
+	}
+	if n := up.calls.Load(); n != 3 {
+		t.Fatalf("upstream calls = %d; want 3", n)
+	}
+}
+
+func TestProxyUncachedWhileDownIsBadGateway(t *testing.T) {
+	up, _, srv, _ := startProxy(t)
+	up.down.Store(true)
+	resp, _ := proxyGet(t, srv.URL+"/starter.zip")
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d; want upstream 503 passed through", resp.StatusCode)
+	}
+
+	p, err := newInitializrProxy("http://127.0.0.1:1", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	p.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/starter.zip", nil))
+	if rec.Code != http.StatusBadGateway {
+		t.Fatalf("unreachable upstream status = %d; want 502", rec.Code)
+	}
+}
+
+func TestProxyPassesClientErrorsUncached(t *testing.T) {
+	up, _, srv, _ := startProxy(t)
+	for i := 0; i < 2; i++ {
+		resp, body := proxyGet(t, srv.URL+"/starter.zip?dependencies=nope")
+		if resp.StatusCode != http.StatusBadRequest || !strings.Contains(body, "Unknown dependency") {
+			t.Fatalf("status=%d body=%s", resp.StatusCode, body)
+		}
+	}
+	if n := up.calls.Load(); n != 2 {
+		t.Fatalf("upstream calls = %d; want 2", n)
+	}
+}
+
+func TestProxyRewritesMetadataLinks(t *testing.T) {
+	_, p, srv, _ := startProxy(t)
+	_, body := proxyGet(t, srv.URL+"/")
+	if strings.Contains(body, p.upstreamOrigin()) || !strings.Contains(body, srv.URL+"/starter.zip") {
+		t.Fatal("metadata links still point at the upstream")
+	}
+}
+
+func TestNormalizeQuery(t *testing.T) {
+	q, _ := url.ParseQuery("type=maven-project&dependencies=web,%20security,web&name=&bootVersion=3.5.5")
+	want := "bootVersion=3.5.5&dependencies=security%2Cweb&type=maven-project"
+	if got := normalizeQuery(q); got != want {
+		t.Fatalf("normalizeQuery = %q; want %q", got, want)
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/mikoto2000/spring-initializr-cli/initializr"
+)
+
+// sharedParam ties a query parameter of a share link or generation URL to
+// the flag it stands for.
+type sharedParam struct {
+	flag  string
+	names []string // the web UI's name first, then API aliases
+	value func(o *options) *string
+}
+
+// sharedParams lists the project settings carried by share links, in the
+// order the start.spring.io web UI writes them. Dependencies are handled
+// separately.
+var sharedParams = []sharedParam{
+	{"type", []string{"type"}, func(o *options) *string { return &o.Type }},
+	{"language", []string{"language"}, func(o *options) *string { return &o.Language }},
+	{"boot-version", []string{"platformVersion", "bootVersion"}, func(o *options) *string { return &o.BootVersion }},
+	{"packaging", []string{"packaging"}, func(o *options) *string { return &o.Packaging }},
+	{"configuration-file-format", []string{"configurationFileFormat"}, func(o *options) *string { return &o.ConfigurationFileFormat }},
+	{"java-version", []string{"jvmVersion", "javaVersion"}, func(o *options) *string { return &o.JavaVersion }},
+	{"group-id", []string{"groupId"}, func(o *options) *string { return &o.GroupID }},
+	{"artifact-id", []string{"artifactId"}, func(o *options) *string { return &o.ArtifactID }},
+	{"name", []string{"name"}, func(o *options) *string { return &o.Name }},
+	{"description", []string{"description"}, func(o *options) *string { return &o.Description }},
+	{"package-name", []string{"packageName"}, func(o *options) *string { return &o.PackageName }},
+	{"base-dir", []string{"baseDir"}, func(o *options) *string { return &o.BaseDir }},
+}
+
+// parseSharedURL splits a start.spring.io share link
+// (https://start.spring.io/#!type=...&dependencies=...) or a generation URL
+// (https://host/starter.zip?type=...) into the server base URL and the
+// project parameters.
+func parseSharedURL(raw string) (string, url.Values, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid URL %q: %w", raw, err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return "", nil, fmt.Errorf("invalid URL %q: want an absolute http(s) URL", raw)
+	}
+	query := u.RawQuery
+	dir := u.Path
+	switch {
+	case strings.HasPrefix(u.Fragment, "!"):
+		query = strings.TrimPrefix(u.EscapedFragment(), "!")
+	case strings.Contains(path.Base(u.Path), "."):
+		// A generation URL such as /starter.zip: the server lives one level up.
+		dir = path.Dir(u.Path)
+	default:
+		return "", nil, fmt.Errorf("%s is neither a share link (#!...) nor a generation URL (/starter.zip?...)", raw)
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid parameters in %q: %w", raw, err)
+	}
+	if len(values) == 0 {
+		return "", nil, errors.New("the URL carries no project parameters")
+	}
+	base := &url.URL{Scheme: u.Scheme, User: u.User, Host: u.Host, Path: strings.TrimRight(dir, "/")}
+	return base.String(), values, nil
+}
+
+// applySharedURL copies the settings of a share link or generation URL into
+// o for every flag not set on the command line, so explicit flags win.
+func applySharedURL(fs *flag.FlagSet, raw string, o *options) error {
+	base, values, err := parseSharedURL(raw)
+	if err != nil {
+		return fmt.Errorf("from-url: %w", err)
+	}
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	if !set["base-url"] {
+		o.baseURL = base
+	}
+	for _, p := range sharedParams {
+		if set[p.flag] {
+			continue
+		}
+		for _, name := range p.names {
+			if v := values.Get(name); v != "" {
+				*p.value(o) = v
+				break
+			}
+		}
+	}
+	if v := values["dependencies"]; len(v) > 0 && !set["dependencies"] {
+		o.Dependencies = initializr.ParseDependencies(strings.Join(v, ","))
+	}
+	return nil
+}
+
+// shareURL returns the start.spring.io web UI link that opens o's settings.
+func shareURL(o options) string {
+	var params []string
+	for _, p := range sharedParams {
+		if p.flag == "base-dir" {
+			continue // not part of the web UI
+		}
+		v := *p.value(&o)
+		if p.flag == "boot-version" {
+			v = initializr.NormalizeBootVersion(v)
+		}
+		if strings.TrimSpace(v) != "" {
+			params = append(params, p.names[0]+"="+escapeShared(v))
+		}
+	}
+	if len(o.Dependencies) > 0 {
+		ids := make([]string, len(o.Dependencies))
+		for i, id := range o.Dependencies {
+			ids[i] = escapeShared(id)
+		}
+		params = append(params, "dependencies="+strings.Join(ids, ","))
+	}
+	return strings.TrimRight(o.baseURL, "/") + "/#!" + strings.Join(params, "&")
+}
+
+// escapeShared escapes a parameter value the way the web UI does, with %20
+// for spaces.
+func escapeShared(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"flag"
+	"strings"
+	"testing"
+
+	"github.com/mikoto2000/spring-initializr-cli/initializr"
+)
+
+// parseShared runs args and --from-url link through a flag set the way
+// parseFlags does.
+func parseShared(t *testing.T, link string, args ...string) options {
+	t.Helper()
+	var o options
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.StringVar(&o.baseURL, "base-url", defaultBaseURL, "")
+	fs.StringVar(&o.Type, "type", "maven-project", "")
+	fs.StringVar(&o.ArtifactID, "artifact-id", "demo", "")
+	fs.Var((*dependencyList)(&o.Dependencies), "dependencies", "")
+	if err := fs.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	if err := applySharedURL(fs, link, &o); err != nil {
+		t.Fatal(err)
+	}
+	return o
+}
+
+func TestFromShareLink(t *testing.T) {
+	link := "https://start.spring.io/#!type=gradle-project-kotlin&language=kotlin&platformVersion=3.5.5&packaging=jar" +
+		"&configurationFileFormat=yaml&jvmVersion=21&groupId=com.acme&artifactId=orders&name=orders" +
+		"&description=Order%20service%20for%20Acme&packageName=com.acme.orders&dependencies=web,data-jpa,postgresql"
+	o := parseShared(t, link)
+	want := initializr.ProjectRequest{
+		Type: "gradle-project-kotlin", Language: "kotlin", BootVersion: "3.5.5", Packaging: "jar",
+		ConfigurationFileFormat: "yaml", JavaVersion: "21", GroupID: "com.acme", ArtifactID: "orders", Name: "orders",
+		Description: "Order service for Acme", PackageName: "com.acme.orders", Dependencies: []string{"web", "data-jpa", "postgresql"},
+	}
+	if o.baseURL != "https://start.spring.io" || o.ProjectRequest.Values().Encode() != want.Values().Encode() {
+		t.Fatalf("got %s %+v", o.baseURL, o.ProjectRequest)
+	}
+	if got := shareURL(o); got != link {
+		t.Fatalf("shareURL round trip:\n got %s\nwant %s", got, link)
+	}
+}
+
+func TestFromStarterURL(t *testing.T) {
+	o := parseShared(t, "https://tools.example/initializr/starter.zip?type=maven-project&bootVersion=3.4.9&javaVersion=17&artifactId=billing&baseDir=billing-svc&dependencies=web&dependencies=actuator")
+	if o.baseURL != "https://tools.example/initializr" {
+		t.Fatalf("baseURL = %q", o.baseURL)
+	}
+	if o.BootVersion != "3.4.9" || o.JavaVersion != "17" || o.ArtifactID != "billing" || o.BaseDir != "billing-svc" ||
+		strings.Join(o.Dependencies, ",") != "web,actuator" {
+		t.Fatalf("options = %+v", o.ProjectRequest)
+	}
+}
+
+func TestFromURLFlagsWin(t *testing.T) {
+	o := parseShared(t, "https://start.spring.io/#!type=gradle-project&artifactId=orders&dependencies=web",
+		"--artifact-id", "payments", "--base-url", "https://initializr.internal", "--dependencies", "webflux")
+	if o.baseURL != "https://initializr.internal" || o.ArtifactID != "payments" || o.Type != "gradle-project" ||
+		strings.Join(o.Dependencies, ",") != "webflux" {
+		t.Fatalf("options = %s %+v", o.baseURL, o.ProjectRequest)
+	}
+}
+
+func TestFromURLRejectsOtherLinks(t *testing.T) {
+	for _, link := range []string{"start.spring.io", "https://start.spring.io/", "https://start.spring.io/#!", "https://start.spring.io/guides"} {
+		if _, _, err := parseSharedURL(link); err == nil {
+			t.Errorf("%q: expected an error", link)
+		}
+	}
+}
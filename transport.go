@@ -0,0 +1,74 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// transportConfig describes how to reach the Initializr server: proxy, extra
+// trusted CAs, a client certificate for mutual TLS and, for labs only,
+// disabled certificate verification.
+type transportConfig struct {
+	proxy                 string // overrides HTTPS_PROXY/HTTP_PROXY/NO_PROXY when set
+	caCert                string // PEM bundle added to the system roots
+	clientCert            string
+	clientKey             string
+	insecureSkipTLSVerify bool
+}
+
+// registerTransportFlags adds the transport flags to fs.
+func registerTransportFlags(fs *flag.FlagSet, c *transportConfig) {
+	fs.StringVar(&c.proxy, "proxy", "", "HTTP(S) proxy URL (default: HTTPS_PROXY/HTTP_PROXY, honoring NO_PROXY)")
+	fs.StringVar(&c.caCert, "ca-cert", "", "PEM file with additional CA certificates to trust")
+	fs.StringVar(&c.clientCert, "client-cert", "", "PEM client certificate for mutual TLS")
+	fs.StringVar(&c.clientKey, "client-key", "", "PEM private key for --client-cert")
+	fs.BoolVar(&c.insecureSkipTLSVerify, "insecure-skip-tls-verify", false, "Do not verify server certificates (labs only; insecure)")
+}
+
+// roundTripper builds the transport shared by downloads and metadata calls.
+func (c transportConfig) roundTripper() (http.RoundTripper, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+
+	t.Proxy = http.ProxyFromEnvironment
+	if c.proxy != "" {
+		u, err := url.Parse(c.proxy)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %q", c.proxy)
+		}
+		t.Proxy = http.ProxyURL(u)
+	}
+
+	tlsConf := &tls.Config{InsecureSkipVerify: c.insecureSkipTLSVerify}
+	if c.caCert != "" {
+		pem, err := os.ReadFile(c.caCert)
+		if err != nil {
+			return nil, fmt.Errorf("read CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", c.caCert)
+		}
+		tlsConf.RootCAs = pool
+	}
+	switch {
+	case c.clientCert != "" && c.clientKey != "":
+		cert, err := tls.LoadX509KeyPair(c.clientCert, c.clientKey)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		tlsConf.Certificates = []tls.Certificate{cert}
+	case c.clientCert != "" || c.clientKey != "":
+		return nil, errors.New("--client-cert and --client-key must be used together")
+	}
+	t.TLSClientConfig = tlsConf
+	return t, nil
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testPKI is a private CA with a server certificate for 127.0.0.1 and a
+// client certificate, written as PEM files into dir.
+type testPKI struct {
+	pool                          *x509.CertPool
+	server                        tls.Certificate
+	caFile, clientCert, clientKey string
+}
+
+func newTestPKI(t *testing.T) *testPKI {
+	t.Helper()
+	dir := t.TempDir()
+	caKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, _ := x509.ParseCertificate(caDER)
+
+	issue := func(serial int64, usage x509.ExtKeyUsage, ips []net.IP) ([]byte, *ecdsa.PrivateKey) {
+		key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		tmpl := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: "test"},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+			IPAddresses:  ips,
+		}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, ca, &key.PublicKey, caKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return der, key
+	}
+	writePEM := func(name, typ string, der []byte) string {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	pki := &testPKI{pool: x509.NewCertPool()}
+	pki.pool.AddCert(ca)
+	pki.caFile = writePEM("ca.pem", "CERTIFICATE", caDER)
+
+	srvDER, srvKey := issue(2, x509.ExtKeyUsageServerAuth, []net.IP{net.ParseIP("127.0.0.1")})
+	pki.server = tls.Certificate{Certificate: [][]byte{srvDER}, PrivateKey: srvKey}
+
+	cliDER, cliKey := issue(3, x509.ExtKeyUsageClientAuth, nil)
+	keyDER, _ := x509.MarshalECPrivateKey(cliKey)
+	pki.clientCert = writePEM("client.pem", "CERTIFICATE", cliDER)
+	pki.clientKey = writePEM("client-key.pem", "EC PRIVATE KEY", keyDER)
+	return pki
+}
+
+func (p *testPKI) mtlsServer(t *testing.T) *httptest.Server {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	srv.TLS = &tls.Config{
+		Certificates: []tls.Certificate{p.server},
+		ClientCAs:    p.pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func get(rt http.RoundTripper, u string) error {
+	resp, err := (&http.Client{Transport: rt, Timeout: 5 * time.Second}).Get(u)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func TestTransportMutualTLS(t *testing.T) {
+	pki := newTestPKI(t)
+	srv := pki.mtlsServer(t)
+
+	cases := []struct {
+		name string
+		conf transportConfig
+		ok   bool
+	}{
+		{"ca and client cert", transportConfig{caCert: pki.caFile, clientCert: pki.clientCert, clientKey: pki.clientKey}, true},
+		{"missing client cert", transportConfig{caCert: pki.caFile}, false},
+		{"unknown CA", transportConfig{clientCert: pki.clientCert, clientKey: pki.clientKey}, false},
+		{"insecure", transportConfig{clientCert: pki.clientCert, clientKey: pki.clientKey, insecureSkipTLSVerify: true}, true},
+	}
+	for _, c := range cases {
+		rt, err := c.conf.roundTripper()
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if err := get(rt, srv.URL); (err == nil) != c.ok {
+			t.Errorf("%s: err = %v; want ok=%v", c.name, err, c.ok)
+		}
+	}
+}
+
+func TestTransportConfigErrors(t *testing.T) {
+	for _, c := range []transportConfig{
+		{clientCert: "cert.pem"},
+		{caCert: filepath.Join(t.TempDir(), "missing.pem")},
+		{proxy: "://bad"},
+	} {
+		if _, err := c.roundTripper(); err == nil {
+			t.Errorf("%+v: expected an error", c)
+		}
+	}
+}
+
+func TestTransportProxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+	}))
+	defer proxy.Close()
+
+	rt, err := transportConfig{proxy: proxy.URL}.roundTripper()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := get(rt, "http://initializr.internal/metadata/client"); err != nil {
+		t.Fatal(err)
+	}
+	if proxied != "http://initializr.internal/metadata/client" {
+		t.Fatalf("proxy saw %q", proxied)
+	}
+}
@@ -1,40 +1,120 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"os"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
+	"github.com/mikoto2000/spring-initializr-cli/initializr"
 	"github.com/rivo/tview"
 )
 
 // runInteractive launches a tview-based TUI for editing options and triggering actions.
-func runInteractive(o options) error {
+// Cancelling ctx closes the TUI; in-flight downloads are cancelled and
+// cleaned up before it returns.
+func runInteractive(ctx context.Context, o options) error {
+	t := newTUI(o, tuiEnv{ctx: ctx, meta: httpMetadata{rt: o.httpTransport}})
+	stop := context.AfterFunc(ctx, t.app.Stop)
+	defer stop()
+	err := t.app.Run()
+	t.close()
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err == nil {
+		fmt.Fprintln(os.Stderr, "Equivalent command:")
+		fmt.Println(t.command())
+	}
+	return err
+}
+
+// tuiEnv holds the TUI's external collaborators so tests can replace them.
+type tuiEnv struct {
+	ctx    context.Context // nil means context.Background()
+	screen tcell.Screen    // nil uses the terminal
+	meta   metadataSource
+}
+
+// metadataSource provides server metadata to the TUI.
+type metadataSource interface {
+	clientMetadata(ctx context.Context, baseURL string, timeout int) (*initializr.Metadata, error)
+	// dependencies returns the catalog of baseURL; m is its metadata when
+	// already loaded, else nil.
+	dependencies(ctx context.Context, baseURL string, timeout int, m *initializr.Metadata) ([]initializr.Dependency, error)
+}
+
+// httpMetadata fetches metadata from the Initializr server over rt (nil
+// means http.DefaultTransport).
+type httpMetadata struct {
+	rt http.RoundTripper
+}
+
+func (m httpMetadata) clientMetadata(ctx context.Context, baseURL string, timeout int) (*initializr.Metadata, error) {
+	return newClient(options{baseURL: baseURL, timeout: timeout, httpTransport: m.rt}).Metadata(ctx)
+}
+
+func (m httpMetadata) dependencies(ctx context.Context, baseURL string, timeout int, meta *initializr.Metadata) ([]initializr.Dependency, error) {
+	c := newClient(options{baseURL: baseURL, timeout: timeout, httpTransport: m.rt})
+	if meta != nil {
+		return c.DependenciesOf(ctx, meta)
+	}
+	return c.Dependencies(ctx)
+}
+
+// tui is a fully wired interactive session, ready to run.
+type tui struct {
+	app         *tview.Application
+	pages       *tview.Pages
+	form        *tview.Form
+	fields      *optionForm
+	status      *tview.TextView
+	readOptions func() options
+	command     func() string // the equivalent non-interactive invocation
+
+	cancel context.CancelFunc // cancels background work
+	work   *sync.WaitGroup    // fetches and downloads that must finish
+}
+
+// close cancels background work and waits until fetches have returned and
+// downloads have removed their partial output.
+func (t *tui) close() {
+	t.cancel()
+	t.work.Wait()
+}
+
+// newTUI builds the interactive session for o without starting it.
+func newTUI(o options, env tuiEnv) *tui {
 	app := tview.NewApplication()
+	if env.screen != nil {
+		app.SetScreen(env.screen)
+	}
+	parent := env.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithCancel(parent)
+	work := &sync.WaitGroup{}
 
 	pages := tview.NewPages()
 
-	depCatalog := make(map[string]depOption) // id -> dep info
+	depCatalog := make(map[string]initializr.Dependency) // id -> dep info
 
-	// Fetch metadata synchronously before building the UI so defaults match server
-	var meta *clientMeta
-	if m, err := fetchClientMetadata(o.baseURL, o.timeout); err == nil {
-		meta = m
-	}
+	// serverMeta is the metadata last loaded, from serverMetaURL; downloads
+	// from that URL reuse it instead of fetching it again.
+	var serverMeta *initializr.Metadata
+	serverMetaURL := ""
 
 	// State: selected dependency IDs
 	selectedDeps := make(map[string]bool)
-	if strings.TrimSpace(o.dependencies) != "" {
-		for _, id := range strings.Split(o.dependencies, ",") {
-			id = strings.TrimSpace(id)
-			if id != "" {
-				selectedDeps[id] = true
-			}
-		}
+	for _, id := range o.Dependencies {
+		selectedDeps[id] = true
 	}
 
 	// Widgets
@@ -43,150 +123,91 @@ func runInteractive(o options) error {
 	form.SetButtonsAlign(tview.AlignRight)
 	form.SetItemPadding(1)
 
-	// DropDown helpers
-	projectTypes := []string{"maven-project", "gradle-project", "gradle-build"}
-	languages := []string{"java", "kotlin", "groovy"}
-	packagings := []string{"jar", "war"}
-
-	ddProjectType := tview.NewDropDown().SetOptions(projectTypes, nil)
-	ddLanguage := tview.NewDropDown().SetOptions(languages, nil)
-	ddPackaging := tview.NewDropDown().SetOptions(packagings, nil)
-	ddConfigFileFormat := tview.NewDropDown().SetOptions([]string{"properties", "yaml"}, nil)
-
-	// Set initial selections (will be overridden by metadata defaults if available)
-	setDropDownValue(ddProjectType, projectTypes, o.projectType)
-	setDropDownValue(ddLanguage, languages, o.language)
-	setDropDownValue(ddPackaging, packagings, o.packaging)
-	setDropDownValue(ddConfigFileFormat, []string{"properties", "yaml"}, o.configFileFormat)
-
-	ddBootVersion := tview.NewDropDown()
-	ddJavaVersion := tview.NewDropDown()
-	inGroupID := tview.NewInputField().SetText(o.groupID)
-	inArtifactID := tview.NewInputField().SetText(o.artifactID)
-	inName := tview.NewInputField().SetText(o.name)
-	inDescription := tview.NewInputField().SetText(o.description)
-	inPackageName := tview.NewInputField().SetText(o.packageName)
-	inBaseURL := tview.NewInputField().SetText(o.baseURL)
+	// Field behavior is wired by key in onChange, assigned below once every
+	// collaborator exists.
+	var onChange func(key string)
+	fields := newOptionForm(form, optionFields, o, func(key string) {
+		if onChange != nil {
+			onChange(key)
+		}
+	})
 
 	// Small helper to pull current form values into options
 	readOptions := func() options {
-		// DropDowns
-		if _, v := ddProjectType.GetCurrentOption(); v != "" {
-			o.projectType = v
-		}
-		if _, v := ddLanguage.GetCurrentOption(); v != "" {
-			o.language = v
-		}
-		if _, v := ddPackaging.GetCurrentOption(); v != "" {
-			o.packaging = v
-		}
-		if _, v := ddBootVersion.GetCurrentOption(); v != "" {
-			o.bootVersion = v
-		}
-		if _, v := ddJavaVersion.GetCurrentOption(); v != "" {
-			o.javaVersion = v
-		}
-		if _, v := ddConfigFileFormat.GetCurrentOption(); v != "" {
-			o.configFileFormat = v
-		}
-		// Inputs
-		o.groupID = strings.TrimSpace(inGroupID.GetText())
-		o.artifactID = strings.TrimSpace(inArtifactID.GetText())
-		o.name = strings.TrimSpace(inName.GetText())
-		o.description = strings.TrimSpace(inDescription.GetText())
-		o.packageName = sanitizePackage(strings.TrimSpace(inPackageName.GetText()))
-		// Base Dir is no longer user-editable in TUI; always mirror Artifact ID
-		o.baseDir = o.artifactID
-		// Output zip name is fixed to <artifactId>.zip in TUI
-		o.output = o.artifactID + ".zip"
-		o.baseURL = strings.TrimSpace(inBaseURL.GetText())
+		curr := fields.read(o)
 		// Dependencies
-		o.dependencies = joinSelected(selectedDeps)
+		curr.Dependencies = selectedIDs(selectedDeps)
 
 		// Derived defaults if empty
 		// Base Dir is always Artifact ID in TUI
-		o.baseDir = o.artifactID
-		if o.packageName == "" {
-			o.packageName = sanitizePackage(o.groupID + "." + o.artifactID)
+		curr.BaseDir = curr.ArtifactID
+		if curr.PackageName == "" {
+			curr.PackageName = initializr.SanitizePackage(curr.GroupID + "." + curr.ArtifactID)
 		}
 		// Output is always <artifactId>.zip in TUI
-		o.output = o.artifactID + ".zip"
-		return o
+		curr.output = curr.ArtifactID + ".zip"
+		return curr
 	}
 
-	// Build form items
-	form.AddFormItem(labeled(ddProjectType, "Project Type"))
-	form.AddFormItem(labeled(ddLanguage, "Language"))
-	// Boot Version dropdown
-	if strings.TrimSpace(o.bootVersion) != "" {
-		ddBootVersion.SetOptions([]string{o.bootVersion}, nil)
-		ddBootVersion.SetCurrentOption(0)
-	}
-	form.AddFormItem(labeled(ddBootVersion, "Boot Version"))
-	// Java Version dropdown
-	if strings.TrimSpace(o.javaVersion) != "" {
-		ddJavaVersion.SetOptions([]string{o.javaVersion}, nil)
-		ddJavaVersion.SetCurrentOption(0)
-	}
-	form.AddFormItem(labeled(ddJavaVersion, "Java Version"))
-	form.AddInputField("Group ID", o.groupID, 0, nil, nil)
-	form.AddInputField("Artifact ID", o.artifactID, 0, nil, nil)
-	form.AddInputField("Name", o.name, 0, nil, nil)
-	form.AddInputField("Description", o.description, 0, nil, nil)
-	form.AddFormItem(labeled(ddPackaging, "Packaging"))
-	form.AddFormItem(labeled(ddConfigFileFormat, "Config File"))
-	form.AddInputField("Package Name", o.packageName, 0, nil, nil)
-	form.AddInputField("Base URL", o.baseURL, 0, nil, nil)
-
-	// Hook form items to variables so readOptions sees updated values
-	// index 2 is Boot Version dropdown, index 3 is Java Version dropdown (no ChangedFunc needed)
 	// Auto-populate Package Name from Group ID and Artifact ID unless manually edited
-	pkgField := form.GetFormItem(10).(*tview.InputField)
 	packageEdited := false
 	updatingPackage := false
-
 	autoUpdatePackage := func() {
 		if packageEdited {
 			return
 		}
-		g := strings.TrimSpace(inGroupID.GetText())
-		a := strings.TrimSpace(inArtifactID.GetText())
+		g := fields.value(fieldGroupID)
+		a := fields.value(fieldArtifactID)
 		if g == "" && a == "" {
 			return
 		}
-		pkg := sanitizePackage(strings.Trim(g+"."+a, "."))
 		updatingPackage = true
-		inPackageName.SetText(pkg)
-		pkgField.SetText(pkg)
+		fields.input(fieldPackageName).SetText(initializr.SanitizePackage(strings.Trim(g+"."+a, ".")))
 		updatingPackage = false
 	}
 
-	form.GetFormItem(4).(*tview.InputField).SetChangedFunc(func(t string) {
-		inGroupID.SetText(t)
-		autoUpdatePackage()
-	})
-	form.GetFormItem(5).(*tview.InputField).SetChangedFunc(func(t string) {
-		inArtifactID.SetText(t)
-		autoUpdatePackage()
-	})
-	form.GetFormItem(6).(*tview.InputField).SetChangedFunc(func(t string) { inName.SetText(t) })
-	form.GetFormItem(7).(*tview.InputField).SetChangedFunc(func(t string) { inDescription.SetText(t) })
-	form.GetFormItem(10).(*tview.InputField).SetChangedFunc(func(t string) {
-		inPackageName.SetText(t)
-		if !updatingPackage {
-			packageEdited = true
+	// Validate as the user types; downloads stay disabled until the form is valid.
+	validate := func() {
+		valid := fields.validate(readOptions())
+		for _, label := range []string{"Download", "Download+Extract"} {
+			if i := form.GetButtonIndex(label); i >= 0 {
+				form.GetButton(i).SetDisabled(!valid)
+			}
 		}
-	})
-	// Indices shift after removing "Base Dir" and "Output Zip" fields
-	form.GetFormItem(11).(*tview.InputField).SetChangedFunc(func(t string) { inBaseURL.SetText(t) })
+	}
 
-	// Buttons
-	var postRun func() error // set when Download/Extract is chosen
+	var reloadTimer *time.Timer
+	var reloadMeta func(baseURL string) // assigned once the status line exists
+	onChange = func(key string) {
+		switch key {
+		case fieldGroupID, fieldArtifactID:
+			autoUpdatePackage()
+		case fieldPackageName:
+			if !updatingPackage {
+				packageEdited = true
+			}
+		case fieldBaseURL:
+			// Debounce: refetch metadata once the user stops typing.
+			if reloadTimer != nil {
+				reloadTimer.Stop()
+			}
+			baseURL := fields.value(fieldBaseURL)
+			reloadTimer = time.AfterFunc(700*time.Millisecond, func() {
+				app.QueueUpdateDraw(func() { reloadMeta(baseURL) })
+			})
+		}
+		validate()
+	}
 
+	// Buttons
 	form.AddButton("Select Dependencies", func() {
 		// fetch and show selector
 		curr := readOptions()
-		showDepsSelector(app, pages, curr.baseURL, curr.timeout, selectedDeps, depCatalog)
+		var meta *initializr.Metadata
+		if curr.baseURL == serverMetaURL {
+			meta = serverMeta
+		}
+		showDepsSelector(ctx, work, app, pages, env.meta, curr.baseURL, curr.timeout, meta, selectedDeps, depCatalog)
 	})
 	form.AddButton("Show Selected", func() {
 		lines := selectedDisplayLines(selectedDeps, depCatalog)
@@ -201,105 +222,157 @@ func runInteractive(o options) error {
 	// Removed: Choose Boot/Java Version (both dropdowns now)
 	form.AddButton("Show URL", func() {
 		curr := readOptions()
-		if u, err := buildURL(curr); err != nil {
+		// The loaded metadata gives the URL a download follows.
+		var meta *initializr.Metadata
+		if curr.baseURL == serverMetaURL {
+			meta = serverMeta
+		}
+		if p, err := projectFor(curr, meta); err != nil {
 			showModal(app, pages, fmt.Sprintf("Error: %v", err), 60*time.Second, nil)
 		} else {
-			showTextModal(app, pages, "Generated URL", u+"\n\nPress Esc or Enter to close.", nil)
+			showTextModal(app, pages, "Generated URL", redactURL(p.url)+"\n\nPress Esc or Enter to close.", nil)
 		}
 	})
-	form.AddButton("Download", func() {
+	// extracted remembers the last download action for the command line.
+	extracted := false
+	command := func() string {
 		curr := readOptions()
-		curr.dryRun = false
-		curr.extract = false
-		curr.interactive = false
-		postRun = func() error { return run(curr) }
-		app.Stop()
+		curr.extract = extracted
+		return commandLine(curr)
+	}
+	form.AddButton("Show Command", func() {
+		showTextModal(app, pages, "Equivalent command", command()+"\n\nPress Esc or Enter to close.", nil)
 	})
-	form.AddButton("Download+Extract", func() {
+	// startDownload generates the project inside the app and reports the
+	// outcome on a result screen; the form stays intact for a retry.
+	startDownload := func(extract bool) {
+		extracted = extract
 		curr := readOptions()
 		curr.dryRun = false
-		curr.extract = true
+		curr.extract = extract
 		curr.interactive = false
-		postRun = func() error { return run(curr) }
-		app.Stop()
-	})
-	form.AddButton("Quit", func() { app.Stop() })
-
-	// If metadata is available, populate dropdown options and set server defaults
-	if meta != nil {
-		if len(meta.Types) > 0 {
-			ddProjectType.SetOptions(meta.Types, nil)
-			// Prefer our CLI default/user choice over server default.
-			setDropDownValue(ddProjectType, meta.Types, o.projectType)
-		}
-		if len(meta.Languages) > 0 {
-			ddLanguage.SetOptions(meta.Languages, nil)
-			if meta.DefaultLanguage != "" {
-				setDropDownValue(ddLanguage, meta.Languages, meta.DefaultLanguage)
-			} else {
-				setDropDownValue(ddLanguage, meta.Languages, o.language)
-			}
-		}
-		if len(meta.Packagings) > 0 {
-			ddPackaging.SetOptions(meta.Packagings, nil)
-			if meta.DefaultPackaging != "" {
-				setDropDownValue(ddPackaging, meta.Packagings, meta.DefaultPackaging)
-			} else {
-				setDropDownValue(ddPackaging, meta.Packagings, o.packaging)
-			}
-		}
-		if len(meta.BootVersions) > 0 {
-			ddBootVersion.SetOptions(meta.BootVersions, nil)
-			if meta.DefaultBootVersion != "" {
-				setDropDownValue(ddBootVersion, meta.BootVersions, meta.DefaultBootVersion)
-			} else if o.bootVersion != "" {
-				setDropDownValue(ddBootVersion, meta.BootVersions, o.bootVersion)
-			}
-		}
-		if len(meta.JavaVersions) > 0 {
-			ddJavaVersion.SetOptions(meta.JavaVersions, nil)
-			if o.javaVersion != "" {
-				setDropDownValue(ddJavaVersion, meta.JavaVersions, o.javaVersion)
-			} else if meta.DefaultJavaVersion != "" {
-				setDropDownValue(ddJavaVersion, meta.JavaVersions, meta.DefaultJavaVersion)
-			}
-		}
-		if len(meta.ConfigFileFormats) > 0 {
-			ddConfigFileFormat.SetOptions(meta.ConfigFileFormats, nil)
-			if o.configFileFormat != "" {
-				setDropDownValue(ddConfigFileFormat, meta.ConfigFileFormats, o.configFileFormat)
-			} else if meta.DefaultConfigFileFormat != "" {
-				setDropDownValue(ddConfigFileFormat, meta.ConfigFileFormats, meta.DefaultConfigFileFormat)
-			}
+		var meta *initializr.Metadata
+		if curr.baseURL == serverMetaURL {
+			meta = serverMeta
 		}
+		showDownloadProgress(ctx, work, app, pages, curr, meta, func(res downloadResult, err error) {
+			showResult(app, pages, res, err)
+		})
 	}
+	form.AddButton("Download", func() { startDownload(false) })
+	form.AddButton("Download+Extract", func() { startDownload(true) })
+	form.AddButton("Quit", func() { app.Stop() })
+
+	validate()
 
 	// Layout
 	frame := tview.NewFrame(form).
 		SetBorders(0, 0, 0, 0, 1, 1).
 		AddText("Tab/Shift+Tab to move, Enter to activate.", true, tview.AlignLeft, tview.Styles.SecondaryTextColor).
 		AddText("Dependencies: Enter/Space toggle, 'd' to done. Use filter.", true, tview.AlignLeft, tview.Styles.SecondaryTextColor)
+	status := tview.NewTextView().SetDynamicColors(true)
+	root := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(frame, 0, 1, true).
+		AddItem(status, 1, 0, false)
+
+	// Load metadata and the dependency catalog in the background; the form is
+	// usable with built-in defaults until they arrive. loadSeq discards results
+	// of requests superseded by a later Base URL change. loadedURL is only set
+	// once a load succeeds, so re-entering a URL retries a failed one.
+	loadSeq := 0
+	loadedURL := ""
+	cancelLoad := func() {}
+	reloadMeta = func(baseURL string) {
+		if baseURL == "" || baseURL == loadedURL {
+			return
+		}
+		loadSeq++
+		seq := loadSeq
+		cancelLoad()
+		var loadCtx context.Context
+		loadCtx, cancelLoad = context.WithCancel(ctx)
+		status.SetText("[yellow]Loading metadata from " + tview.Escape(baseURL) + "...")
+		// Loads are part of work so that nothing, such as the --debug-http
+		// log, is still written to once the session has closed.
+		work.Add(1)
+		go func() {
+			defer work.Done()
+			meta, err := env.meta.clientMetadata(loadCtx, baseURL, o.timeout)
+			var deps []initializr.Dependency
+			var depsErr error
+			if err == nil {
+				deps, depsErr = env.meta.dependencies(loadCtx, baseURL, o.timeout, meta)
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			app.QueueUpdateDraw(func() {
+				if seq != loadSeq {
+					return
+				}
+				if err != nil {
+					loadedURL = ""
+					status.SetText("[red]Metadata unavailable, using built-in defaults: " + tview.Escape(err.Error()))
+					return
+				}
+				loadedURL = baseURL
+				serverMeta, serverMetaURL = meta, baseURL
+				fields.applyMeta(meta, o)
+				validate()
+				// The catalog describes the loaded server only.
+				for id := range depCatalog {
+					delete(depCatalog, id)
+				}
+				if depsErr != nil {
+					status.SetText("[red]Metadata loaded from " + tview.Escape(baseURL) + ", but its dependencies are unavailable: " + tview.Escape(depsErr.Error()))
+					return
+				}
+				for _, d := range deps {
+					if d.ID != "" {
+						depCatalog[d.ID] = d
+					}
+				}
+				msg := "[green]Metadata loaded from " + tview.Escape(baseURL)
+				if missing := missingDeps(selectedDeps, depCatalog); len(missing) > 0 {
+					msg = "[yellow]Selected dependencies not available on " + tview.Escape(baseURL) + ": " + strings.Join(missing, ", ")
+				}
+				status.SetText(msg)
+			})
+		}()
+	}
+	reloadMeta(o.baseURL)
 
-	pages.AddPage("main", frame, true, true)
+	pages.AddPage("main", root, true, true)
+	app.SetRoot(pages, true).EnableMouse(true)
 
-	if err := app.SetRoot(pages, true).EnableMouse(true).Run(); err != nil {
-		return err
-	}
-	if postRun != nil {
-		return postRun()
-	}
-	return nil
+	return &tui{app: app, pages: pages, form: form, fields: fields, status: status, readOptions: readOptions, command: command, cancel: cancel, work: work}
 }
 
-// labeled wraps a form item with a label since DropDown lacks SetLabel in older tview.
-func labeled(item tview.FormItem, label string) tview.FormItem {
-	switch it := item.(type) {
-	case *tview.DropDown:
-		it.SetLabel(label + ": ")
-	default:
-		// pass-through
+// errorItem decorates a form item with validation text drawn to the right of
+// its field. An empty err draws nothing.
+type errorItem struct {
+	tview.FormItem
+	labelWidth int
+	err        string
+}
+
+func (e *errorItem) SetFormAttributes(labelWidth int, labelColor, bgColor, fieldTextColor, fieldBgColor tcell.Color) tview.FormItem {
+	e.labelWidth = labelWidth
+	e.FormItem.SetFormAttributes(labelWidth, labelColor, bgColor, fieldTextColor, fieldBgColor)
+	return e
+}
+
+func (e *errorItem) Draw(screen tcell.Screen) {
+	e.FormItem.Draw(screen)
+	if e.err == "" {
+		return
 	}
-	return item
+	x, y, width, _ := e.GetRect()
+	offset := e.labelWidth + e.GetFieldWidth() + 1
+	if offset >= width {
+		return
+	}
+	tview.Print(screen, tview.Escape(e.err), x+offset, y, width-offset, tview.AlignLeft, tcell.ColorRed)
 }
 
 func setDropDownValue(dd *tview.DropDown, options []string, val string) {
@@ -313,15 +386,13 @@ func setDropDownValue(dd *tview.DropDown, options []string, val string) {
 	dd.SetCurrentOption(idx)
 }
 
-func joinSelected(m map[string]bool) string {
-	ids := make([]string, 0, len(m))
-	for id, ok := range m {
-		if ok {
-			ids = append(ids, id)
+func containsFold(values []string, val string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, val) {
+			return true
 		}
 	}
-	sort.Strings(ids)
-	return strings.Join(ids, ",")
+	return false
 }
 
 func selectedIDs(m map[string]bool) []string {
@@ -336,7 +407,8 @@ func selectedIDs(m map[string]bool) []string {
 }
 
 // selectedDisplayLines returns lines formatted as "Name (ID) [Group]" if available.
-func selectedDisplayLines(selected map[string]bool, catalog map[string]depOption) []string {
+// IDs missing from a loaded catalog are flagged as not available.
+func selectedDisplayLines(selected map[string]bool, catalog map[string]initializr.Dependency) []string {
 	ids := selectedIDs(selected)
 	if len(ids) == 0 {
 		return nil
@@ -353,6 +425,8 @@ func selectedDisplayLines(selected map[string]bool, catalog map[string]depOption
 			} else {
 				out = append(out, fmt.Sprintf("%s (%s)", name, id))
 			}
+		} else if len(catalog) > 0 {
+			out = append(out, id+" (not available on this server)")
 		} else {
 			out = append(out, id)
 		}
@@ -360,137 +434,41 @@ func selectedDisplayLines(selected map[string]bool, catalog map[string]depOption
 	return out
 }
 
-type depOption struct {
-	ID    string
-	Name  string
-	Group string
-}
-
-// clientMeta holds selected lists parsed from /metadata/client
-type clientMeta struct {
-	Types                   []string
-	Languages               []string
-	Packagings              []string
-	JavaVersions            []string
-	BootVersions            []string
-	ConfigFileFormats       []string
-	DefaultType             string
-	DefaultLanguage         string
-	DefaultPackaging        string
-	DefaultJavaVersion      string
-	DefaultBootVersion      string
-	DefaultConfigFileFormat string
-}
-
-// fetchClientMetadata returns lists from /metadata/client for dropdowns and pickers.
-func fetchClientMetadata(baseURL string, timeout int) (*clientMeta, error) {
-	client := &http.Client{Timeout: time.Duration(timeout) * time.Second}
-	base := strings.TrimRight(baseURL, "/")
-	data, err := fetchMetadataPayload(client, base)
-	if err != nil {
-		return nil, err
-	}
-	type valueItem struct {
-		ID      string `json:"id"`
-		Name    string `json:"name"`
-		Default bool   `json:"default"`
-	}
-	type section struct {
-		Default string      `json:"default"`
-		Values  []valueItem `json:"values"`
-	}
-	extractIDs := func(key string) ([]string, string) {
-		raw, ok := data[key]
-		if !ok {
-			return nil, ""
-		}
-		var sec section
-		if err := json.Unmarshal(raw, &sec); err != nil {
-			return nil, ""
-		}
-		out := make([]string, 0, len(sec.Values))
-		for _, v := range sec.Values {
-			if v.ID != "" {
-				out = append(out, v.ID)
-			}
-		}
-		def := sec.Default
-		if def == "" {
-			for _, v := range sec.Values {
-				if v.Default {
-					def = v.ID
-					break
-				}
-			}
-		}
-		return out, def
-	}
-	types, defType := extractIDs("type")
-	langs, defLang := extractIDs("language")
-	packs, defPack := extractIDs("packaging")
-	javas, defJava := extractIDs("javaVersion")
-	boots, defBoot := extractIDs("bootVersion")
-	cfgFormats, defCfgFormat := extractIDs("configurationFileFormat")
-	m := &clientMeta{
-		Types:                   types,
-		Languages:               langs,
-		Packagings:              packs,
-		JavaVersions:            javas,
-		BootVersions:            boots,
-		ConfigFileFormats:       cfgFormats,
-		DefaultType:             defType,
-		DefaultLanguage:         defLang,
-		DefaultPackaging:        defPack,
-		DefaultJavaVersion:      defJava,
-		DefaultBootVersion:      defBoot,
-		DefaultConfigFileFormat: defCfgFormat,
-	}
-	return m, nil
-}
-
-func fetchMetadataPayload(client *http.Client, base string) (map[string]json.RawMessage, error) {
-	endpoints := []string{base + "/", base + "/metadata/client"}
-	var lastErr error
-	for _, endpoint := range endpoints {
-		req, _ := http.NewRequest(http.MethodGet, endpoint, nil)
-		req.Header.Set("Accept", "application/vnd.initializr.v2.3+json, application/json")
-		resp, err := client.Do(req)
-		if err != nil {
-			lastErr = err
-			continue
-		}
-		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-			lastErr = fmt.Errorf("status %s", resp.Status)
-			resp.Body.Close()
-			continue
-		}
-		var data map[string]json.RawMessage
-		err = json.NewDecoder(resp.Body).Decode(&data)
-		resp.Body.Close()
-		if err != nil {
-			lastErr = err
-			continue
+// missingDeps returns the selected IDs that are absent from the catalog.
+func missingDeps(selected map[string]bool, catalog map[string]initializr.Dependency) []string {
+	var out []string
+	for _, id := range selectedIDs(selected) {
+		if _, ok := catalog[id]; !ok {
+			out = append(out, id)
 		}
-		return data, nil
 	}
-	if lastErr != nil {
-		return nil, lastErr
-	}
-	return nil, fmt.Errorf("metadata unavailable from %s", base)
+	return out
 }
 
-func showDepsSelector(app *tview.Application, pages *tview.Pages, baseURL string, timeout int, selected map[string]bool, catalog map[string]depOption) {
+func showDepsSelector(ctx context.Context, work *sync.WaitGroup, app *tview.Application, pages *tview.Pages, src metadataSource, baseURL string, timeout int, meta *initializr.Metadata, selected map[string]bool, catalog map[string]initializr.Dependency) {
 	// Show loading modal while fetching
+	session := ctx
+	ctx, cancel := context.WithCancel(ctx)
 	loading := tview.NewModal().SetText("Fetching dependencies...\n(Press Esc to cancel)")
 	loading.AddButtons([]string{"Cancel"}).SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+		cancel()
 		pages.RemovePage("loading")
 	})
 	pages.AddPage("loading", centered(loading, 0.4, 0.3), true, true)
 
+	work.Add(1)
 	go func() {
-		deps, err := fetchDependencies(baseURL, timeout)
+		defer work.Done()
+		defer cancel()
+		deps, err := src.dependencies(ctx, baseURL, timeout, meta)
+		if session.Err() != nil {
+			return
+		}
 		app.QueueUpdateDraw(func() {
 			pages.RemovePage("loading")
+			if ctx.Err() != nil {
+				return // cancelled by the user
+			}
 			if err != nil {
 				showModal(app, pages, fmt.Sprintf("Failed to fetch dependencies:\n%v", err), 8*time.Second, nil)
 				return
@@ -519,12 +497,12 @@ func showDepsSelector(app *tview.Application, pages *tview.Pages, baseURL string
 			type depRow struct {
 				header bool
 				group  string
-				dep    depOption
+				dep    initializr.Dependency
 			}
 			rows := []depRow{}
 
 			// Helper to build visible list from filter, grouped
-			filtered := make([]depOption, len(deps))
+			filtered := make([]initializr.Dependency, len(deps))
 			copy(filtered, deps)
 			rebuild := func() {
 				q := strings.ToLower(strings.TrimSpace(filter.GetText()))
@@ -647,7 +625,78 @@ func showDepsSelector(app *tview.Application, pages *tview.Pages, baseURL string
 	}()
 }
 
-func depLabel(d depOption, checked bool) string {
+// showDownloadProgress runs the download in the background behind a modal
+// with a Cancel button. Once ctx (the session) is done, no further UI updates
+// are queued; work lets the session wait for cleanup to finish.
+func showDownloadProgress(ctx context.Context, work *sync.WaitGroup, app *tview.Application, pages *tview.Pages, o options, meta *initializr.Metadata, onDone func(res downloadResult, err error)) {
+	dctx, cancel := context.WithCancel(ctx)
+	modal := tview.NewModal().SetText("Downloading...").
+		AddButtons([]string{"Cancel"}).
+		SetDoneFunc(func(int, string) { cancel() })
+	pages.AddPage("progress", centered(modal, 0.5, 0.3), true, true)
+	app.SetFocus(modal)
+
+	start := time.Now()
+	progress := throttleProgress(func(received, total int64) {
+		if ctx.Err() != nil {
+			return
+		}
+		app.QueueUpdateDraw(func() {
+			modal.SetText("Downloading...\n" + formatProgress(received, total, time.Since(start)))
+		})
+	}, 100*time.Millisecond)
+
+	work.Add(1)
+	go func() {
+		defer work.Done()
+		defer cancel()
+		res, err := downloadWith(dctx, o, meta, progress)
+		if ctx.Err() != nil {
+			return
+		}
+		app.QueueUpdateDraw(func() {
+			pages.RemovePage("progress")
+			onDone(res, err)
+		})
+	}()
+}
+
+// showResult presents the outcome of a download. "Back" returns to the form
+// so the user can adjust options and retry; "Quit" leaves the app.
+func showResult(app *tview.Application, pages *tview.Pages, res downloadResult, err error) {
+	var text string
+	if errors.Is(err, context.Canceled) {
+		text = "Download cancelled. No files were left behind."
+	} else if err != nil {
+		text = fmt.Sprintf("Generation failed:\n%v\n\nGo back to fix the form and retry.", err)
+	} else {
+		text = fmt.Sprintf("Project generated.\n\nOutput: %s\nFiles: %d", res.path, res.files)
+		if len(res.mirrored) > 0 {
+			text += "\nMirrors: " + strings.Join(res.mirrored, ", ")
+		}
+		if res.overlaid > 0 || len(res.overlaySkipped) > 0 {
+			text += fmt.Sprintf("\nOverlay files: %d (%d skipped)", res.overlaid, len(res.overlaySkipped))
+		}
+		if res.gitInit {
+			text += "\nGit: repository initialized"
+		} else if res.gitEnclosing != "" {
+			text += "\nGit: skipped, already inside " + res.gitEnclosing
+		}
+	}
+	modal := tview.NewModal().SetText(text).
+		AddButtons([]string{"Back", "Quit"}).
+		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+			if buttonLabel == "Quit" {
+				app.Stop()
+				return
+			}
+			pages.RemovePage("result")
+		})
+	pages.AddPage("result", centered(modal, 0.6, 0.4), true, true)
+	app.SetFocus(modal)
+}
+
+func depLabel(d initializr.Dependency, checked bool) string {
 	mark := "☐"
 	if checked {
 		mark = "☑"
@@ -732,115 +781,3 @@ func showStringPicker(app *tview.Application, pages *tview.Pages, title string,
 	pages.AddPage("picker", centered(list, 0.5, 0.7), true, true)
 	app.SetFocus(list)
 }
-
-// Fetch dependencies from Initializr metadata endpoints, tolerating schema variants.
-func fetchDependencies(baseURL string, timeout int) ([]depOption, error) {
-	client := &http.Client{Timeout: time.Duration(timeout) * time.Second}
-	base := strings.TrimRight(baseURL, "/")
-
-	// Try API root first (v2.3), then legacy endpoint
-	if deps, err := fetchFromMetadataClient(client, base+"/"); err == nil && len(deps) > 0 {
-		return deps, nil
-	}
-	// Try /metadata/client first
-	if deps, err := fetchFromMetadataClient(client, base+"/metadata/client"); err == nil && len(deps) > 0 {
-		return deps, nil
-	}
-	// Fallback to /dependencies
-	if deps, err := fetchFromDependencies(client, base+"/dependencies"); err == nil && len(deps) > 0 {
-		return deps, nil
-	} else if err != nil {
-		return nil, err
-	}
-	return nil, fmt.Errorf("no dependencies found from %s", base)
-}
-
-func fetchFromMetadataClient(client *http.Client, url string) ([]depOption, error) {
-	req, _ := http.NewRequest(http.MethodGet, url, nil)
-	req.Header.Set("Accept", "application/vnd.initializr.v2.3+json, application/json")
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("status %s", resp.Status)
-	}
-	var data struct {
-		Dependencies struct {
-			Values []struct {
-				Name   string            `json:"name"`
-				Values []json.RawMessage `json:"values"`
-			} `json:"values"`
-		} `json:"dependencies"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return nil, err
-	}
-	var out []depOption
-	for _, grp := range data.Dependencies.Values {
-		gname := grp.Name
-		for _, raw := range grp.Values {
-			var item struct {
-				ID   string `json:"id"`
-				Name string `json:"name"`
-			}
-			if err := json.Unmarshal(raw, &item); err == nil && item.ID != "" {
-				out = append(out, depOption{ID: item.ID, Name: item.Name, Group: gname})
-			}
-		}
-	}
-	return out, nil
-}
-
-func fetchFromDependencies(client *http.Client, url string) ([]depOption, error) {
-	req, _ := http.NewRequest(http.MethodGet, url, nil)
-	req.Header.Set("Accept", "application/vnd.initializr.v2.3+json, application/json")
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("status %s", resp.Status)
-	}
-
-	// tolerate both {groups:[{name,values:[{id,name}]}]} and {dependencies:[{id,name,group}]}
-	var raw map[string]json.RawMessage
-	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
-		return nil, err
-	}
-	if graw, ok := raw["groups"]; ok {
-		var groups []struct {
-			Name   string `json:"name"`
-			Values []struct {
-				ID   string `json:"id"`
-				Name string `json:"name"`
-			} `json:"values"`
-		}
-		if err := json.Unmarshal(graw, &groups); err == nil {
-			var out []depOption
-			for _, g := range groups {
-				for _, v := range g.Values {
-					out = append(out, depOption{ID: v.ID, Name: v.Name, Group: g.Name})
-				}
-			}
-			return out, nil
-		}
-	}
-	if draw, ok := raw["dependencies"]; ok {
-		var deps []struct {
-			ID    string `json:"id"`
-			Name  string `json:"name"`
-			Group string `json:"group"`
-		}
-		if err := json.Unmarshal(draw, &deps); err == nil {
-			out := make([]depOption, 0, len(deps))
-			for _, d := range deps {
-				out = append(out, depOption{ID: d.ID, Name: d.Name, Group: d.Group})
-			}
-			return out, nil
-		}
-	}
-	return nil, fmt.Errorf("unsupported dependencies schema")
-}
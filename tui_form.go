@@ -0,0 +1,275 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/mikoto2000/spring-initializr-cli/initializr"
+	"github.com/rivo/tview"
+)
+
+// fieldKind selects the widget used for a form field.
+type fieldKind int
+
+const (
+	fieldInput fieldKind = iota
+	fieldDropDown
+)
+
+// Field keys used for wiring behavior between fields. Fields are looked up
+// by key, never by position, so the order of optionFields can change freely.
+const (
+	fieldType             = "type"
+	fieldLanguage         = "language"
+	fieldBootVersion      = "bootVersion"
+	fieldJavaVersion      = "javaVersion"
+	fieldGroupID          = "groupId"
+	fieldArtifactID       = "artifactId"
+	fieldName             = "name"
+	fieldDescription      = "description"
+	fieldPackaging        = "packaging"
+	fieldConfigFileFormat = "configurationFileFormat"
+	fieldPackageName      = "packageName"
+	fieldBaseURL          = "baseURL"
+)
+
+// formField declares one option field of the TUI form: its label, widget,
+// binding to options, validation and (for dropdowns) metadata source.
+type formField struct {
+	key   string
+	label string
+	kind  fieldKind
+
+	// choices are the built-in dropdown options used until metadata arrives.
+	choices []string
+
+	get func(o options) string
+	set func(o *options, v string)
+
+	// validate reports a problem with the field given the whole form state.
+	// Validated inputs get a fixed width so the error fits next to them.
+	validate func(o options) error
+
+	// fromMeta returns the dropdown options advertised by the server and the
+	// value to preselect.
+	fromMeta func(m *initializr.Metadata, o options) (values []string, preferred string)
+}
+
+// optionFields is the TUI form, in display order.
+var optionFields = []formField{
+	{
+		key: fieldType, label: "Project Type", kind: fieldDropDown,
+		choices: []string{"maven-project", "gradle-project", "gradle-build"},
+		get:     func(o options) string { return o.Type },
+		set:     func(o *options, v string) { o.Type = v },
+		fromMeta: func(m *initializr.Metadata, o options) ([]string, string) {
+			// Prefer our CLI default/user choice over server default.
+			return m.Type.IDs(), o.Type
+		},
+	},
+	{
+		key: fieldLanguage, label: "Language", kind: fieldDropDown,
+		choices: []string{"java", "kotlin", "groovy"},
+		get:     func(o options) string { return o.Language },
+		set:     func(o *options, v string) { o.Language = v },
+		fromMeta: func(m *initializr.Metadata, o options) ([]string, string) {
+			return m.Language.IDs(), firstNonEmpty(m.Language.DefaultID(), o.Language)
+		},
+	},
+	{
+		key: fieldBootVersion, label: "Boot Version", kind: fieldDropDown,
+		get: func(o options) string { return o.BootVersion },
+		set: func(o *options, v string) { o.BootVersion = v },
+		fromMeta: func(m *initializr.Metadata, o options) ([]string, string) {
+			return m.BootVersion.IDs(), firstNonEmpty(m.BootVersion.DefaultID(), o.BootVersion)
+		},
+	},
+	{
+		key: fieldJavaVersion, label: "Java Version", kind: fieldDropDown,
+		get:      func(o options) string { return o.JavaVersion },
+		set:      func(o *options, v string) { o.JavaVersion = v },
+		validate: func(o options) error { return validateJavaBoot(o.BootVersion, o.JavaVersion) },
+		fromMeta: func(m *initializr.Metadata, o options) ([]string, string) {
+			return m.JavaVersion.IDs(), firstNonEmpty(o.JavaVersion, m.JavaVersion.DefaultID())
+		},
+	},
+	{
+		key: fieldGroupID, label: "Group ID", kind: fieldInput,
+		get:      func(o options) string { return o.GroupID },
+		set:      func(o *options, v string) { o.GroupID = v },
+		validate: func(o options) error { return validateJavaPackage(o.GroupID) },
+	},
+	{
+		key: fieldArtifactID, label: "Artifact ID", kind: fieldInput,
+		get:      func(o options) string { return o.ArtifactID },
+		set:      func(o *options, v string) { o.ArtifactID = v },
+		validate: func(o options) error { return validateArtifactID(o.ArtifactID) },
+	},
+	{
+		key: fieldName, label: "Name", kind: fieldInput,
+		get: func(o options) string { return o.Name },
+		set: func(o *options, v string) { o.Name = v },
+	},
+	{
+		key: fieldDescription, label: "Description", kind: fieldInput,
+		get: func(o options) string { return o.Description },
+		set: func(o *options, v string) { o.Description = v },
+	},
+	{
+		key: fieldPackaging, label: "Packaging", kind: fieldDropDown,
+		choices: []string{"jar", "war"},
+		get:     func(o options) string { return o.Packaging },
+		set:     func(o *options, v string) { o.Packaging = v },
+		fromMeta: func(m *initializr.Metadata, o options) ([]string, string) {
+			return m.Packaging.IDs(), firstNonEmpty(m.Packaging.DefaultID(), o.Packaging)
+		},
+	},
+	{
+		key: fieldConfigFileFormat, label: "Config File", kind: fieldDropDown,
+		choices: []string{"properties", "yaml"},
+		get:     func(o options) string { return o.ConfigurationFileFormat },
+		set:     func(o *options, v string) { o.ConfigurationFileFormat = v },
+		fromMeta: func(m *initializr.Metadata, o options) ([]string, string) {
+			return m.ConfigurationFileFormat.IDs(), firstNonEmpty(o.ConfigurationFileFormat, m.ConfigurationFileFormat.DefaultID())
+		},
+	},
+	{
+		key: fieldPackageName, label: "Package Name", kind: fieldInput,
+		get:      func(o options) string { return o.PackageName },
+		set:      func(o *options, v string) { o.PackageName = initializr.SanitizePackage(v) },
+		validate: func(o options) error { return validateJavaPackage(o.PackageName) },
+	},
+	{
+		key: fieldBaseURL, label: "Base URL", kind: fieldInput,
+		get: func(o options) string { return o.baseURL },
+		set: func(o *options, v string) { o.baseURL = v },
+	},
+}
+
+// optionForm holds the widgets created from a list of formFields.
+type optionForm struct {
+	fields []formField
+	items  map[string]*errorItem
+	// initial remembers dropdown values as last set programmatically, so a
+	// metadata refresh can tell whether the user changed them since.
+	initial  map[string]string
+	onChange func(key string)
+}
+
+// newOptionForm creates widgets for fields, adds them to form and seeds them
+// from o. onChange is called with the field key whenever a value changes.
+func newOptionForm(form *tview.Form, fields []formField, o options, onChange func(key string)) *optionForm {
+	f := &optionForm{fields: fields, items: map[string]*errorItem{}, initial: map[string]string{}, onChange: onChange}
+	for _, fld := range fields {
+		key := fld.key
+		var item tview.FormItem
+		switch fld.kind {
+		case fieldDropDown:
+			dd := tview.NewDropDown().SetLabel(fld.label + ": ")
+			choices := fld.choices
+			if v := strings.TrimSpace(fld.get(o)); len(choices) == 0 && v != "" {
+				choices = []string{v}
+			}
+			dd.SetOptions(choices, nil)
+			setDropDownValue(dd, choices, fld.get(o))
+			dd.SetSelectedFunc(func(string, int) { f.onChange(key) })
+			_, f.initial[key] = dd.GetCurrentOption()
+			item = dd
+		default:
+			in := tview.NewInputField().SetLabel(fld.label).SetText(fld.get(o))
+			if fld.validate != nil {
+				in.SetFieldWidth(40)
+			}
+			in.SetChangedFunc(func(string) { f.onChange(key) })
+			item = in
+		}
+		ei := &errorItem{FormItem: item}
+		f.items[key] = ei
+		form.AddFormItem(ei)
+	}
+	return f
+}
+
+func (f *optionForm) input(key string) *tview.InputField {
+	return f.items[key].FormItem.(*tview.InputField)
+}
+
+func (f *optionForm) dropDown(key string) *tview.DropDown {
+	return f.items[key].FormItem.(*tview.DropDown)
+}
+
+// value returns the current text of the field with the given key.
+func (f *optionForm) value(key string) string {
+	switch it := f.items[key].FormItem.(type) {
+	case *tview.DropDown:
+		_, v := it.GetCurrentOption()
+		return v
+	case *tview.InputField:
+		return strings.TrimSpace(it.GetText())
+	}
+	return ""
+}
+
+// read copies all field values into o. Dropdowns without a selection keep
+// the value already in o.
+func (f *optionForm) read(o options) options {
+	for _, fld := range f.fields {
+		v := f.value(fld.key)
+		if fld.kind == fieldDropDown && v == "" {
+			continue
+		}
+		fld.set(&o, v)
+	}
+	return o
+}
+
+// validate stores each field's error next to it and reports whether the
+// whole form is valid.
+func (f *optionForm) validate(o options) bool {
+	valid := true
+	for _, fld := range f.fields {
+		item := f.items[fld.key]
+		item.err = ""
+		if fld.validate == nil {
+			continue
+		}
+		if err := fld.validate(o); err != nil {
+			item.err = err.Error()
+			valid = false
+		}
+	}
+	return valid
+}
+
+// applyMeta replaces dropdown options with server metadata. Values the user
+// changed since the last refresh are kept when the server still offers them.
+func (f *optionForm) applyMeta(m *initializr.Metadata, o options) {
+	for _, fld := range f.fields {
+		if fld.kind != fieldDropDown || fld.fromMeta == nil {
+			continue
+		}
+		values, preferred := fld.fromMeta(m, o)
+		if len(values) == 0 {
+			continue
+		}
+		dd := f.dropDown(fld.key)
+		_, curr := dd.GetCurrentOption()
+		if curr != f.initial[fld.key] && containsFold(values, curr) {
+			preferred = curr
+		}
+		// Swap options without triggering the change hook, then restore it.
+		key := fld.key
+		dd.SetOptions(values, nil)
+		setDropDownValue(dd, values, preferred)
+		dd.SetSelectedFunc(func(string, int) { f.onChange(key) })
+		_, f.initial[key] = dd.GetCurrentOption()
+	}
+}
+
+func firstNonEmpty(vals ...string) string {
+	for _, v := range vals {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
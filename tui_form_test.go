@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/mikoto2000/spring-initializr-cli/initializr"
+	"github.com/rivo/tview"
+)
+
+func TestOptionFormReadIsOrderIndependent(t *testing.T) {
+	o := options{
+		baseURL: defaultBaseURL,
+		ProjectRequest: initializr.ProjectRequest{
+			Type:        "gradle-project",
+			Language:    "kotlin",
+			GroupID:     "com.example",
+			ArtifactID:  "demo",
+			PackageName: "com.example.demo",
+		},
+	}
+	reversed := make([]formField, len(optionFields))
+	for i, f := range optionFields {
+		reversed[len(optionFields)-1-i] = f
+	}
+	for _, fields := range [][]formField{optionFields, reversed} {
+		f := newOptionForm(tview.NewForm(), fields, o, func(string) {})
+		f.input(fieldArtifactID).SetText("other")
+		got := f.read(o)
+		if got.ArtifactID != "other" || got.GroupID != "com.example" || got.Type != "gradle-project" || got.Language != "kotlin" {
+			t.Fatalf("read mismatch: %+v", got)
+		}
+	}
+}
+
+func TestOptionFormValidate(t *testing.T) {
+	o := options{ProjectRequest: initializr.ProjectRequest{GroupID: "com.class", ArtifactID: "demo", PackageName: "com.example.demo"}}
+	f := newOptionForm(tview.NewForm(), optionFields, o, func(string) {})
+	if f.validate(f.read(o)) {
+		t.Fatal("expected form to be invalid")
+	}
+	if f.items[fieldGroupID].err == "" {
+		t.Fatal("expected an error next to Group ID")
+	}
+	if f.items[fieldArtifactID].err != "" {
+		t.Fatalf("unexpected Artifact ID error: %q", f.items[fieldArtifactID].err)
+	}
+}
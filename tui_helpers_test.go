@@ -1,10 +1,15 @@
 package main
 
-import "testing"
+import (
+    "strings"
+    "testing"
+
+    "github.com/mikoto2000/spring-initializr-cli/initializr"
+)
 
 func TestSelectedDisplayLines(t *testing.T) {
     selected := map[string]bool{"web": true, "data-jpa": true, "security": false}
-    catalog := map[string]depOption{
+    catalog := map[string]initializr.Dependency{
         "web":      {ID: "web", Name: "Spring Web", Group: "Web"},
         "data-jpa": {ID: "data-jpa", Name: "Spring Data JPA", Group: "SQL"},
     }
@@ -21,11 +26,30 @@ func TestSelectedDisplayLines(t *testing.T) {
     }
 }
 
-func TestJoinSelected(t *testing.T) {
+func TestSelectedIDs(t *testing.T) {
     selected := map[string]bool{"b": true, "a": true, "c": false}
-    s := joinSelected(selected)
+    s := strings.Join(selectedIDs(selected), ",")
     if s != "a,b" {
-        t.Fatalf("joinSelected = %q; want a,b", s)
+        t.Fatalf("selectedIDs = %q; want a,b", s)
     }
 }
 
+
+func TestMissingDepsFlagged(t *testing.T) {
+    selected := map[string]bool{"web": true, "legacy": true}
+    catalog := map[string]initializr.Dependency{
+        "web": {ID: "web", Name: "Spring Web", Group: "Web"},
+    }
+    missing := missingDeps(selected, catalog)
+    if len(missing) != 1 || missing[0] != "legacy" {
+        t.Fatalf("missingDeps = %v; want [legacy]", missing)
+    }
+    lines := selectedDisplayLines(selected, catalog)
+    if lines[0] != "legacy (not available on this server)" {
+        t.Fatalf("bad line[0]: %q", lines[0])
+    }
+    // Without a catalog nothing can be flagged.
+    if lines := selectedDisplayLines(selected, map[string]initializr.Dependency{}); lines[0] != "legacy" {
+        t.Fatalf("unexpected flag without catalog: %q", lines[0])
+    }
+}
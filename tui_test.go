@@ -0,0 +1,469 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/mikoto2000/spring-initializr-cli/initializr"
+	"github.com/mikoto2000/spring-initializr-cli/initializrtest"
+	"github.com/rivo/tview"
+)
+
+// fakeMetadata serves canned metadata to the TUI.
+type fakeMetadata struct {
+	meta *initializr.Metadata
+	deps []initializr.Dependency
+}
+
+func (f fakeMetadata) clientMetadata(context.Context, string, int) (*initializr.Metadata, error) {
+	if f.meta == nil {
+		return nil, errors.New("offline")
+	}
+	return f.meta, nil
+}
+
+func (f fakeMetadata) dependencies(context.Context, string, int, *initializr.Metadata) ([]initializr.Dependency, error) {
+	if f.deps == nil {
+		return nil, errors.New("offline")
+	}
+	return f.deps, nil
+}
+
+var testMetadata = fakeMetadata{
+	meta: &initializr.Metadata{
+		Type:                    selectOf("", "maven-project", "gradle-project"),
+		Language:                selectOf("", "java", "kotlin"),
+		Packaging:               selectOf("", "jar", "war"),
+		JavaVersion:             selectOf("17", "17", "21"),
+		BootVersion:             selectOf("3.5.5", "3.5.5", "3.4.9"),
+		ConfigurationFileFormat: selectOf("", "properties", "yaml"),
+	},
+	deps: []initializr.Dependency{
+		{ID: "web", Name: "Spring Web", Group: "Web"},
+		{ID: "data-jpa", Name: "Spring Data JPA", Group: "SQL"},
+		{ID: "security", Name: "Spring Security", Group: "Security"},
+	},
+}
+
+func selectOf(def string, ids ...string) initializr.SingleSelect {
+	s := initializr.SingleSelect{Default: def}
+	for _, id := range ids {
+		s.Values = append(s.Values, initializr.Option{ID: id})
+	}
+	return s
+}
+
+// keySync is a key the harness sends after scripted input; once the app
+// sees it, every earlier key has been handled.
+const keySync = tcell.KeyF64
+
+// tuiHarness runs a TUI against a simulation screen and scripts keystrokes.
+type tuiHarness struct {
+	t      *testing.T
+	ui     *tui
+	screen tcell.SimulationScreen
+	synced chan struct{}
+	done   chan error
+}
+
+func startTUI(t *testing.T, o options, src metadataSource) *tuiHarness {
+	t.Helper()
+	screen := tcell.NewSimulationScreen("UTF-8")
+	h := &tuiHarness{
+		t:      t,
+		ui:     newTUI(o, tuiEnv{screen: screen, meta: src}),
+		screen: screen,
+		synced: make(chan struct{}),
+		done:   make(chan error, 1),
+	}
+	h.ui.app.SetInputCapture(func(ev *tcell.EventKey) *tcell.EventKey {
+		if ev.Key() == keySync {
+			h.synced <- struct{}{}
+			return nil
+		}
+		return ev
+	})
+	go func() { h.done <- h.ui.app.Run() }()
+	t.Cleanup(h.stop)
+	h.waitFor("metadata", func() bool { return strings.Contains(h.ui.status.GetText(true), "Metadata") })
+	return h
+}
+
+func (h *tuiHarness) stop() {
+	h.ui.app.Stop()
+	select {
+	case err := <-h.done:
+		if err != nil {
+			h.t.Errorf("app.Run: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		h.t.Error("app did not stop")
+	}
+	h.ui.close()
+}
+
+// press sends keys and waits until the app has handled them.
+func (h *tuiHarness) press(keys ...tcell.Key) {
+	h.t.Helper()
+	for _, k := range keys {
+		h.screen.PostEventWait(tcell.NewEventKey(k, 0, tcell.ModNone))
+	}
+	h.sync()
+}
+
+// typeText sends s as rune keys and waits until the app has handled them.
+func (h *tuiHarness) typeText(s string) {
+	h.t.Helper()
+	for _, r := range s {
+		h.screen.PostEventWait(tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone))
+	}
+	h.sync()
+}
+
+func (h *tuiHarness) sync() {
+	h.t.Helper()
+	h.screen.PostEventWait(tcell.NewEventKey(keySync, 0, tcell.ModNone))
+	select {
+	case <-h.synced:
+	case <-time.After(5 * time.Second):
+		h.t.Fatal("timed out waiting for the app to handle input")
+	}
+}
+
+// do runs fn on the UI goroutine and waits for it.
+func (h *tuiHarness) do(fn func()) {
+	h.t.Helper()
+	ran := make(chan struct{})
+	h.ui.app.QueueUpdate(func() {
+		fn()
+		close(ran)
+	})
+	select {
+	case <-ran:
+	case <-time.After(5 * time.Second):
+		h.t.Fatal("timed out waiting for the UI goroutine")
+	}
+}
+
+// waitFor polls cond on the UI goroutine until it holds.
+func (h *tuiHarness) waitFor(what string, cond func() bool) {
+	h.t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		var ok bool
+		h.do(func() { ok = cond() })
+		if ok {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	h.t.Fatalf("timed out waiting for %s", what)
+}
+
+func (h *tuiHarness) options() options {
+	var o options
+	h.do(func() { o = h.ui.readOptions() })
+	return o
+}
+
+// focusField tabs from the first form item to the field with key.
+func (h *tuiHarness) focusField(key string) {
+	h.t.Helper()
+	for i, f := range optionFields {
+		if f.key == key {
+			h.do(func() {
+				h.ui.form.SetFocus(i)
+				h.ui.app.SetFocus(h.ui.form)
+			})
+			return
+		}
+	}
+	h.t.Fatalf("no field %q", key)
+}
+
+func (h *tuiHarness) focusButton(label string) {
+	h.t.Helper()
+	h.do(func() {
+		h.ui.form.SetFocus(h.ui.form.GetFormItemCount() + h.ui.form.GetButtonIndex(label))
+		h.ui.app.SetFocus(h.ui.form)
+	})
+}
+
+func (h *tuiHarness) focused() tview.Primitive {
+	var p tview.Primitive
+	h.do(func() { p = h.ui.app.GetFocus() })
+	return p
+}
+
+func defaultTestOptions() options {
+	return options{
+		baseURL: "http://initializr.test",
+		timeout: 1,
+		ProjectRequest: initializr.ProjectRequest{
+			Type:        "maven-project",
+			Language:    "java",
+			GroupID:     "com.example",
+			ArtifactID:  "demo",
+			PackageName: "com.example.demo",
+			Packaging:   "jar",
+		},
+	}
+}
+
+func TestTUI_MetadataPopulatesDropDowns(t *testing.T) {
+	h := startTUI(t, defaultTestOptions(), testMetadata)
+	o := h.options()
+	if o.BootVersion != "3.5.5" || o.JavaVersion != "17" {
+		t.Fatalf("metadata defaults not applied: boot=%q java=%q", o.BootVersion, o.JavaVersion)
+	}
+}
+
+func TestTUI_PackageNameFollowsGroupAndArtifact(t *testing.T) {
+	h := startTUI(t, defaultTestOptions(), testMetadata)
+
+	h.focusField(fieldGroupID)
+	h.typeText(".acme")
+	if got := h.options().PackageName; got != "com.example.acme.demo" {
+		t.Fatalf("packageName after group edit = %q", got)
+	}
+
+	h.press(tcell.KeyTab) // Artifact ID
+	h.press(tcell.KeyBackspace2, tcell.KeyBackspace2, tcell.KeyBackspace2, tcell.KeyBackspace2)
+	h.typeText("Shop-App")
+	if got := h.options().PackageName; got != "com.example.acme.shopapp" {
+		t.Fatalf("packageName after artifact edit = %q", got)
+	}
+
+	// Once the package is edited by hand it is no longer overwritten.
+	h.focusField(fieldPackageName)
+	h.typeText("x")
+	h.focusField(fieldGroupID)
+	h.typeText("z")
+	o := h.options()
+	if o.GroupID != "com.example.acmez" || o.PackageName != "com.example.acme.shopappx" {
+		t.Fatalf("manual package edit lost: group=%q package=%q", o.GroupID, o.PackageName)
+	}
+}
+
+func TestTUI_ShowCommand(t *testing.T) {
+	h := startTUI(t, defaultTestOptions(), testMetadata)
+
+	h.focusField(fieldArtifactID)
+	h.press(tcell.KeyBackspace2, tcell.KeyBackspace2, tcell.KeyBackspace2, tcell.KeyBackspace2)
+	h.typeText("orders")
+	h.focusButton("Show Command")
+	h.press(tcell.KeyEnter)
+	h.waitFor("command modal", func() bool { return h.ui.pages.HasPage("text") })
+
+	var got string
+	h.do(func() { got = h.ui.command() })
+	if !strings.HasPrefix(got, "spring-initializr-cli ") || !strings.Contains(got, "--artifact-id orders") {
+		t.Fatalf("command = %q", got)
+	}
+	// Derived values match what the CLI would derive and are left out.
+	for _, flag := range []string{"--package-name", "--base-dir", "--output", "--interactive"} {
+		if strings.Contains(got, flag) {
+			t.Fatalf("command has %s: %q", flag, got)
+		}
+	}
+}
+
+func TestTUI_InvalidFormDisablesDownload(t *testing.T) {
+	h := startTUI(t, defaultTestOptions(), testMetadata)
+	disabled := func() bool {
+		var d bool
+		h.do(func() { d = h.ui.form.GetButton(h.ui.form.GetButtonIndex("Download")).IsDisabled() })
+		return d
+	}
+	if disabled() {
+		t.Fatal("Download disabled for a valid form")
+	}
+	h.focusField(fieldGroupID)
+	h.typeText(".class")
+	if !disabled() {
+		t.Fatal("Download enabled with a reserved word in Group ID")
+	}
+	h.press(tcell.KeyBackspace2, tcell.KeyBackspace2, tcell.KeyBackspace2, tcell.KeyBackspace2, tcell.KeyBackspace2, tcell.KeyBackspace2)
+	if disabled() {
+		t.Fatal("Download still disabled after fixing Group ID")
+	}
+}
+
+func TestTUI_DependencySelectorKeys(t *testing.T) {
+	h := startTUI(t, defaultTestOptions(), testMetadata)
+	openSelector := func() {
+		h.focusButton("Select Dependencies")
+		h.press(tcell.KeyEnter)
+		h.waitFor("dependency selector", func() bool { return h.ui.pages.HasPage("deps") })
+	}
+	isFilter := func(p tview.Primitive) bool {
+		in, ok := p.(*tview.InputField)
+		return ok && in.GetLabel() == "Filter: "
+	}
+
+	openSelector()
+	if !isFilter(h.focused()) {
+		t.Fatalf("filter not focused on open: %T", h.focused())
+	}
+
+	// Filter, move to the list with Tab, skip the group header, toggle with Space.
+	h.typeText("jpa")
+	h.press(tcell.KeyTab)
+	if _, ok := h.focused().(*tview.List); !ok {
+		t.Fatalf("Tab did not move focus to the list: %T", h.focused())
+	}
+	h.press(tcell.KeyDown)
+	h.typeText(" ")
+	if got := strings.Join(h.options().Dependencies, ","); got != "data-jpa" {
+		t.Fatalf("dependencies after Space = %q", got)
+	}
+	// Checking an item clears the filter and refocuses it.
+	if !isFilter(h.focused()) {
+		t.Fatalf("filter not refocused after toggle: %T", h.focused())
+	}
+
+	// Keys typed in the list must not leak into the filter; '/' returns to it.
+	h.typeText("web")
+	h.press(tcell.KeyDown) // to the list
+	h.typeText("/")
+	if !isFilter(h.focused()) {
+		t.Fatalf("'/' did not focus the filter: %T", h.focused())
+	}
+	h.press(tcell.KeyEnter) // back to the list
+	h.press(tcell.KeyDown)
+	h.press(tcell.KeyEnter) // toggle web
+	if got := strings.Join(h.options().Dependencies, ","); got != "data-jpa,web" {
+		t.Fatalf("dependencies after Enter = %q", got)
+	}
+
+	// 'd' closes the selector from the list.
+	h.press(tcell.KeyTab)
+	h.typeText("d")
+	if got := strings.Join(h.options().Dependencies, ","); got != "data-jpa,web" {
+		t.Fatalf("'d' changed dependencies: %q", got)
+	}
+	h.waitFor("selector closed by 'd'", func() bool { return !h.ui.pages.HasPage("deps") })
+
+	// Esc closes the selector from the filter.
+	openSelector()
+	h.press(tcell.KeyEsc)
+	h.waitFor("selector closed by Esc", func() bool { return !h.ui.pages.HasPage("deps") })
+}
+
+// flakyMetadata fails its first clientMetadata calls.
+type flakyMetadata struct {
+	fakeMetadata
+	failures *atomic.Int32
+}
+
+func (f flakyMetadata) clientMetadata(ctx context.Context, baseURL string, timeout int) (*initializr.Metadata, error) {
+	if f.failures.Add(-1) >= 0 {
+		return nil, errors.New("connection refused")
+	}
+	return f.fakeMetadata.clientMetadata(ctx, baseURL, timeout)
+}
+
+func TestTUI_RetriesFailedMetadataLoad(t *testing.T) {
+	src := flakyMetadata{testMetadata, new(atomic.Int32)}
+	src.failures.Store(1)
+	h := startTUI(t, defaultTestOptions(), src)
+	if s := h.ui.status.GetText(true); !strings.Contains(s, "Metadata unavailable") {
+		t.Fatalf("status = %q", s)
+	}
+
+	// Editing the Base URL back to the same value loads it again.
+	h.focusField(fieldBaseURL)
+	h.typeText("x")
+	h.press(tcell.KeyBackspace2)
+	h.waitFor("metadata retried", func() bool { return strings.Contains(h.ui.status.GetText(true), "Metadata loaded") })
+}
+
+func TestTUI_ReportsDependencyLoadError(t *testing.T) {
+	o := defaultTestOptions()
+	o.Dependencies = []string{"web"}
+	h := startTUI(t, o, fakeMetadata{meta: testMetadata.meta})
+	if s := h.ui.status.GetText(true); !strings.Contains(s, "dependencies are unavailable: offline") {
+		t.Fatalf("status = %q", s)
+	}
+}
+
+// slowMetadata answers only once its request is cancelled.
+type slowMetadata struct {
+	fakeMetadata
+	returned *atomic.Bool
+}
+
+func (s slowMetadata) clientMetadata(ctx context.Context, _ string, _ int) (*initializr.Metadata, error) {
+	<-ctx.Done()
+	time.Sleep(50 * time.Millisecond)
+	s.returned.Store(true)
+	return nil, ctx.Err()
+}
+
+func TestTUI_CloseWaitsForMetadataLoad(t *testing.T) {
+	src := slowMetadata{testMetadata, new(atomic.Bool)}
+	ui := newTUI(defaultTestOptions(), tuiEnv{screen: tcell.NewSimulationScreen("UTF-8"), meta: src})
+	ui.close()
+	if !src.returned.Load() {
+		t.Fatal("close returned while the metadata load was still running")
+	}
+}
+
+func TestTUI_LoadsFromMockServer(t *testing.T) {
+	srv := initializrtest.NewServer()
+	defer srv.Close()
+	o := defaultTestOptions()
+	o.baseURL = srv.URL
+	h := startTUI(t, o, httpMetadata{})
+	if s := h.ui.status.GetText(true); !strings.Contains(s, "Metadata loaded") {
+		t.Fatalf("status = %q", s)
+	}
+	if got := h.options().BootVersion; got != "3.5.5" {
+		t.Fatalf("bootVersion = %q; want server default 3.5.5", got)
+	}
+}
+
+func TestTUI_ReusesLoadedMetadata(t *testing.T) {
+	var metadataRequests atomic.Int32
+	mock := initializrtest.NewHandler()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" || r.URL.Path == "/metadata/client" {
+			metadataRequests.Add(1)
+		}
+		mock.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+	t.Chdir(t.TempDir())
+	o := defaultTestOptions()
+	o.baseURL = srv.URL
+	o.Type = "gradle-build"
+	h := startTUI(t, o, httpMetadata{})
+	loaded := metadataRequests.Load()
+	if loaded != 1 {
+		t.Fatalf("loading the form fetched the metadata %d times", loaded)
+	}
+
+	h.focusButton("Select Dependencies")
+	h.press(tcell.KeyEnter)
+	h.waitFor("selector", func() bool { return h.ui.pages.HasPage("deps") })
+	h.press(tcell.KeyEsc)
+	h.waitFor("selector closed", func() bool { return !h.ui.pages.HasPage("deps") })
+
+	h.focusButton("Download")
+	h.press(tcell.KeyEnter)
+	h.waitFor("result", func() bool { return h.ui.pages.HasPage("result") })
+	if b, err := os.ReadFile("build.gradle"); err != nil || !strings.Contains(string(b), "plugins {") {
+		t.Fatalf("build.gradle = %q, %v", b, err)
+	}
+	if n := metadataRequests.Load() - loaded; n != 0 {
+		t.Fatalf("the selector and download fetched the metadata %d more times", n)
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mikoto2000/spring-initializr-cli/initializr"
+)
+
+// isBuildFile reports whether path, relative to the project root, is a build
+// or wrapper file compared by the upgrade command.
+func isBuildFile(path string) bool {
+	switch path {
+	case "pom.xml", "build.gradle", "build.gradle.kts", "settings.gradle", "settings.gradle.kts",
+		"gradle.properties", "mvnw", "mvnw.cmd", "gradlew", "gradlew.bat":
+		return true
+	}
+	return strings.HasPrefix(path, ".mvn/wrapper/") || strings.HasPrefix(path, "gradle/wrapper/")
+}
+
+// runUpgrade shows, and with --apply merges, what Initializr generates
+// differently for a project when only the Spring Boot version changes.
+func runUpgrade(ctx context.Context, args []string) error {
+	var o options
+	fs := flag.NewFlagSet("upgrade", flag.ExitOnError)
+	target := fs.String("to", "", "Spring Boot version to upgrade to (required)")
+	apply := fs.Bool("apply", false, "Apply the changes that do not conflict with local edits to the project")
+	fs.StringVar(&o.baseURL, "base-url", defaultBaseURL, "Spring Initializr base URL")
+	registerProjectFlags(fs, &o)
+	fs.IntVar(&o.timeout, "timeout", 60, "Download timeout in seconds")
+	fs.BoolVar(&o.verbose, "v", false, "Verbose output")
+	fs.BoolVar(&o.debugHTTP, "debug-http", false, "Log HTTP requests and responses (secrets redacted) to stderr")
+	configPath := fs.String("config", defaultConfigPath(), "JSON config file (flags override its values)")
+	registerMirrorFlags(fs, &o.mirrors)
+	registerTransportFlags(fs, &o.transport)
+	registerAuthFlags(fs, &o.auth)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: spring-initializr-cli upgrade --to <boot-version> [--apply] [options] [project-dir]\n\n")
+		fmt.Fprintf(os.Stderr, "Generates the project at its current and at the target Spring Boot version\nand prints a unified diff of the build and wrapper files. The project's\nsettings come from its %s (see --write-descriptor) and the flags.\nWith --apply, changes that do not conflict with local edits are merged\ninto the project directory (default: .).\n\n", descriptorName)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() > 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	dir := "."
+	if fs.NArg() == 1 {
+		dir = fs.Arg(0)
+	}
+	if *target == "" {
+		return errors.New("upgrade: --to is required")
+	}
+
+	if err := applyConfig(fs, *configPath, &o); err != nil {
+		return err
+	}
+	_, err := os.Stat(filepath.Join(dir, descriptorName))
+	hasDescriptor := err == nil
+	if hasDescriptor {
+		if err := applyDescriptor(fs, dir, &o, true); err != nil {
+			return err
+		}
+	}
+	o.target = "zip"
+	o.fillDefaults()
+	if o.BootVersion == "" {
+		return fmt.Errorf("upgrade: the project's Spring Boot version is unknown; pass --boot-version or generate the project with --write-descriptor")
+	}
+	// The projects compared are generated in memory, where mirrors apply.
+	if err := o.mirrors.check(true); err != nil {
+		return err
+	}
+	if err := o.prepareTransport(); err != nil {
+		return err
+	}
+
+	from, to := o, o
+	from.BootVersion = initializr.NormalizeBootVersion(o.BootVersion)
+	to.BootVersion = initializr.NormalizeBootVersion(*target)
+	if from.BootVersion == to.BootVersion {
+		fmt.Fprintf(os.Stderr, "The project is already on Spring Boot %s\n", to.BootVersion)
+		return nil
+	}
+
+	p, err := resolveProject(ctx, from)
+	if err != nil {
+		return err
+	}
+	if metadataChanged(o, p) {
+		fmt.Fprintln(os.Stderr, "warning: the server's metadata changed since the descriptor was written; the diff may include unrelated changes")
+	}
+	q, err := projectFor(to, p.meta)
+	if err != nil {
+		return err
+	}
+	oldFiles, err := generateFiles(ctx, from, p)
+	if err != nil {
+		return fmt.Errorf("generating Spring Boot %s: %w", from.BootVersion, err)
+	}
+	newFiles, err := generateFiles(ctx, to, q)
+	if err != nil {
+		return fmt.Errorf("generating Spring Boot %s: %w", to.BootVersion, err)
+	}
+
+	changes := compareFiles(oldFiles, newFiles, isBuildFile)
+	if len(changes) == 0 {
+		fmt.Fprintf(os.Stderr, "No build or wrapper file changes between Spring Boot %s and %s\n", from.BootVersion, to.BootVersion)
+	}
+	for _, c := range changes {
+		writeChange(os.Stdout, c)
+	}
+	if !*apply {
+		return nil
+	}
+
+	conflicts := 0
+	for _, c := range changes {
+		n, err := applyChange(dir, c, os.Stderr)
+		if err != nil {
+			return err
+		}
+		conflicts += n
+	}
+	if conflicts > 0 {
+		return fmt.Errorf("%d change(s) conflict with local edits and were not applied; merge them by hand from the diff above", conflicts)
+	}
+	if hasDescriptor {
+		if err := writeDescriptor(dir, to, q); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "updated %s\n", descriptorName)
+	}
+	return nil
+}
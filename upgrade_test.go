@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mikoto2000/spring-initializr-cli/initializrtest"
+)
+
+// generateOldProject extracts demo/ at Spring Boot 3.4.9 with a descriptor.
+func generateOldProject(t *testing.T, baseURL string) {
+	t.Helper()
+	o := mockOptions(t, baseURL)
+	o.BootVersion = "3.4.9"
+	o.Dependencies = []string{"web"}
+	o.extract = true
+	o.writeDescriptor = true
+	if _, err := download(context.Background(), o, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func editFile(t *testing.T, path, before, after string) {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), before) {
+		t.Fatalf("%s does not contain %q", path, before)
+	}
+	os.WriteFile(path, []byte(strings.Replace(string(b), before, after, 1)), 0o644)
+}
+
+func TestUpgradeAppliesBuildChanges(t *testing.T) {
+	srv := initializrtest.NewServer()
+	defer srv.Close()
+	generateOldProject(t, srv.URL)
+	editFile(t, "demo/pom.xml", "<java.version>17</java.version>", "<java.version>21</java.version>")
+
+	var err error
+	out := captureStdout(t, func() {
+		err = runUpgrade(context.Background(), []string{"--config", "", "--to", "3.5.5", "--apply", "demo"})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"--- a/pom.xml\n+++ b/pom.xml\n",
+		"-\t\t<version>3.4.9</version>\n+\t\t<version>3.5.5</version>\n",
+		"+++ b/.mvn/wrapper/maven-wrapper.properties\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("diff lacks %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "HELP.md") {
+		t.Fatalf("diff covers non-build files:\n%s", out)
+	}
+
+	pom, _ := os.ReadFile("demo/pom.xml")
+	if !strings.Contains(string(pom), "<version>3.5.5</version>") || !strings.Contains(string(pom), "<java.version>21</java.version>") {
+		t.Fatalf("pom.xml:\n%s", pom)
+	}
+	wrapper, _ := os.ReadFile("demo/.mvn/wrapper/maven-wrapper.properties")
+	if !strings.Contains(string(wrapper), "apache-maven-3.9.11-bin.zip") {
+		t.Fatalf("wrapper:\n%s", wrapper)
+	}
+	d, err := loadDescriptor("demo")
+	if err != nil || d.Request.BootVersion != "3.5.5" || d.Request.BaseDir != "demo" {
+		t.Fatalf("descriptor = %+v, %v", d.Request, err)
+	}
+}
+
+func TestUpgradeReportsConflicts(t *testing.T) {
+	srv := initializrtest.NewServer()
+	defer srv.Close()
+	generateOldProject(t, srv.URL)
+	wrapperPath := filepath.Join("demo", ".mvn", "wrapper", "maven-wrapper.properties")
+	editFile(t, wrapperPath, "repo.maven.apache.org/maven2", "mirror.example/maven2")
+
+	var err error
+	captureStdout(t, func() {
+		err = runUpgrade(context.Background(), []string{"--config", "", "--to", "3.5.5", "--apply", "demo"})
+	})
+	if err == nil || !strings.Contains(err.Error(), "1 change(s) conflict") {
+		t.Fatalf("err = %v", err)
+	}
+	wrapper, _ := os.ReadFile(wrapperPath)
+	if !strings.Contains(string(wrapper), "mirror.example/maven2/org/apache/maven/apache-maven/3.9.9/") {
+		t.Fatalf("conflicting file changed:\n%s", wrapper)
+	}
+	pom, _ := os.ReadFile("demo/pom.xml")
+	if !strings.Contains(string(pom), "<version>3.5.5</version>") {
+		t.Fatal("non-conflicting file not patched")
+	}
+	if d, _ := loadDescriptor("demo"); d.Request.BootVersion != "3.4.9" {
+		t.Fatalf("descriptor updated despite conflicts: %q", d.Request.BootVersion)
+	}
+}
+
+func TestUpgradeKeepsRecordedMirrors(t *testing.T) {
+	srv := initializrtest.NewServer()
+	defer srv.Close()
+	o := mockOptions(t, srv.URL)
+	o.BootVersion = "3.4.9"
+	o.extract = true
+	o.writeDescriptor = true
+	o.mirrors.maven = "https://mirror.example/maven2"
+	if _, err := download(context.Background(), o, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Upgrading without --maven-mirror regenerates with the recorded mirror,
+	// so the mirrored wrapper is patched instead of conflicting.
+	var err error
+	captureStdout(t, func() {
+		err = runUpgrade(context.Background(), []string{"--config", "", "--to", "3.5.5", "--apply", "demo"})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrapper, _ := os.ReadFile(filepath.Join("demo", ".mvn", "wrapper", "maven-wrapper.properties"))
+	if !strings.Contains(string(wrapper), "mirror.example/maven2/org/apache/maven/apache-maven/3.9.11/") {
+		t.Fatalf("wrapper:\n%s", wrapper)
+	}
+	d, err := loadDescriptor("demo")
+	if err != nil || d.MavenMirror != o.mirrors.maven || d.Request.BootVersion != "3.5.5" {
+		t.Fatalf("descriptor = %+v, %v", d, err)
+	}
+}
+
+func TestUpgradeNeedsBootVersion(t *testing.T) {
+	t.Chdir(t.TempDir())
+	err := runUpgrade(context.Background(), []string{"--config", "", "--base-url", "http://initializr.invalid", "--to", "3.5.5"})
+	if err == nil || !strings.Contains(err.Error(), "--boot-version") {
+		t.Fatalf("err = %v", err)
+	}
+}
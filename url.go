@@ -1,98 +1,77 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"net/url"
-	"regexp"
+	"path"
 	"strings"
+	"time"
+
+	"github.com/mikoto2000/spring-initializr-cli/initializr"
 )
 
-// buildURL constructs the Initializr starter URL from options.
+// buildURL constructs the Initializr starter URL from options without
+// contacting the server.
 func buildURL(o options) (string, error) {
-	if o.baseURL == "" {
-		return "", errors.New("base-url must not be empty")
-	}
-	base := strings.TrimRight(o.baseURL, "/") + "/starter."
-	switch strings.ToLower(o.target) {
-	case "zip":
-		base += "zip"
-	default:
+	if strings.ToLower(o.target) != "zip" {
 		return "", errors.New("unsupported target: " + o.target)
 	}
+	return newClient(o).StarterURL(o.ProjectRequest)
+}
 
-	q := url.Values{}
-	add := func(k, v string) {
-		if strings.TrimSpace(v) != "" {
-			q.Set(k, v)
-		}
-	}
+// resolvedProject is where a project is generated from.
+type resolvedProject struct {
+	url  string
+	meta *initializr.Metadata // nil when the server's metadata was unavailable
+
+	// buildFile names the lone file a build type such as gradle-build
+	// generates, e.g. build.gradle; it is empty for project archives.
+	buildFile string
+}
 
-	add("type", o.projectType)
-	add("language", o.language)
-	// Normalize historical suffix styles (e.g., .RELEASE, .BUILD-SNAPSHOT, .M7, .RC1)
-	add("bootVersion", normalizeBootVersion(o.bootVersion))
-	add("baseDir", o.baseDir)
-	add("groupId", o.groupID)
-	add("artifactId", o.artifactID)
-	add("name", o.name)
-	add("description", o.description)
-	add("packageName", o.packageName)
-	add("packaging", o.packaging)
-	add("javaVersion", o.javaVersion)
-	add("configurationFileFormat", o.configFileFormat)
+// metadataTimeout bounds the metadata lookup of resolveProject, so that a
+// server whose metadata endpoints do not answer delays generation by this
+// much at most before the fallback, rather than by --timeout per endpoint.
+var metadataTimeout = 5 * time.Second
 
-	deps := strings.TrimSpace(o.dependencies)
-	if deps != "" {
-		// normalize: remove whitespace around commas
-		parts := strings.Split(deps, ",")
-		for i := range parts {
-			parts[i] = strings.TrimSpace(parts[i])
+// resolveProject finds the URL to generate o from, following the link or
+// action the server advertises for o.Type. Without metadata it falls back
+// to buildURL.
+func resolveProject(ctx context.Context, o options) (resolvedProject, error) {
+	if strings.ToLower(o.target) != "zip" {
+		return resolvedProject{}, errors.New("unsupported target: " + o.target)
+	}
+	mctx, cancel := context.WithTimeout(ctx, metadataTimeout)
+	defer cancel()
+	m, err := newClient(o).Metadata(mctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return resolvedProject{}, err
 		}
-		q.Set("dependencies", strings.Join(parts, ","))
+		return projectFor(o, nil)
 	}
-
-	return base + "?" + q.Encode(), nil
+	return projectFor(o, m)
 }
 
-// sanitizePackage normalizes a Java package name string from user inputs.
-func sanitizePackage(s string) string {
-	s = strings.ToLower(s)
-	replacer := strings.NewReplacer("-", "", " ", "")
-	s = replacer.Replace(s)
-	for strings.Contains(s, "..") {
-		s = strings.ReplaceAll(s, "..", ".")
+// projectFor is resolveProject with the metadata already fetched; m may be
+// nil.
+func projectFor(o options, m *initializr.Metadata) (resolvedProject, error) {
+	if m == nil {
+		u, err := buildURL(o)
+		return resolvedProject{url: u}, err
 	}
-	s = strings.Trim(s, ".")
-	return s
-}
-
-// normalizeBootVersion converts historical Spring Boot version notations
-// to the forms accepted by modern Initializr servers.
-// Examples:
-//   - 3.5.5.RELEASE        -> 3.5.5
-//   - 2.0.0.BUILD-SNAPSHOT -> 2.0.0-SNAPSHOT
-//   - 2.0.0.M7             -> 2.0.0-M7
-//   - 2.0.0.RC1            -> 2.0.0-RC1
-func normalizeBootVersion(s string) string {
-	s = strings.TrimSpace(s)
-	if s == "" {
-		return s
+	u, err := newClient(o).ProjectURL(m, o.ProjectRequest)
+	if err != nil {
+		return resolvedProject{}, err
 	}
-	// RELEASE suffix: drop it (accept both ".RELEASE" and "-RELEASE")
-	s = strings.TrimSuffix(s, ".RELEASE")
-	s = strings.TrimSuffix(s, "-RELEASE")
-
-	// BUILD-SNAPSHOT: convert to -SNAPSHOT
-	s = strings.ReplaceAll(s, ".BUILD-SNAPSHOT", "-SNAPSHOT")
-	s = strings.ReplaceAll(s, "-BUILD-SNAPSHOT", "-SNAPSHOT")
-	// Rare: handle ".SNAPSHOT" -> "-SNAPSHOT"
-	if strings.HasSuffix(s, ".SNAPSHOT") {
-		s = strings.TrimSuffix(s, ".SNAPSHOT") + "-SNAPSHOT"
+	p := resolvedProject{url: u, meta: m}
+	if m.IsBuildType(o.Type) {
+		pu, err := url.Parse(u)
+		if err != nil {
+			return resolvedProject{}, err
+		}
+		p.buildFile = path.Base(pu.Path)
 	}
-
-	// Convert ".M<digits>" and ".RC<digits>" at the end to hyphenated form.
-	re := regexp.MustCompile(`\.(M|RC)(\d+)$`)
-	s = re.ReplaceAllString(s, "-$1$2")
-
-	return s
+	return p, nil
 }
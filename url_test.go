@@ -1,43 +1,38 @@
 package main
 
 import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"reflect"
+	"strings"
 	"testing"
-)
+	"time"
 
-func TestSanitizePackage(t *testing.T) {
-	cases := []struct{ in, out string }{
-		{"Com.Example.Demo", "com.example.demo"},
-		{"com-example demo", "comexampledemo"},
-		{"..com..example..demo..", "com.example.demo"},
-		{"-A-.-B-", "a.b"},
-		{"", ""},
-	}
-	for _, c := range cases {
-		if got := sanitizePackage(c.in); got != c.out {
-			t.Errorf("sanitizePackage(%q) = %q; want %q", c.in, got, c.out)
-		}
-	}
-}
+	"github.com/mikoto2000/spring-initializr-cli/initializr"
+)
 
 func TestBuildURL(t *testing.T) {
 	o := options{
-		baseURL:          "https://start.spring.io",
-		target:           "zip",
-		projectType:      "maven-project",
-		language:         "java",
-		bootVersion:      "3.3.4",
-		groupID:          "com.example",
-		artifactID:       "demo",
-		name:             "demo",
-		description:      "Demo project",
-		packageName:      "com.example.demo",
-		packaging:        "jar",
-		javaVersion:      "21",
-		configFileFormat: "yaml",
-		dependencies:     "web,data-jpa , security",
-		baseDir:          "demo",
+		baseURL: "https://start.spring.io",
+		target:  "zip",
+		ProjectRequest: initializr.ProjectRequest{
+			Type:                    "maven-project",
+			Language:                "java",
+			BootVersion:             "3.3.4",
+			GroupID:                 "com.example",
+			ArtifactID:              "demo",
+			Name:                    "demo",
+			Description:             "Demo project",
+			PackageName:             "com.example.demo",
+			Packaging:               "jar",
+			JavaVersion:             "21",
+			ConfigurationFileFormat: "yaml",
+			Dependencies:            initializr.ParseDependencies("web,data-jpa , security"),
+			BaseDir:                 "demo",
+		},
 	}
 	u, err := buildURL(o)
 	if err != nil {
@@ -71,42 +66,23 @@ func TestBuildURL(t *testing.T) {
 	}
 }
 
-func TestNormalizeBootVersion_VariousLegacyForms(t *testing.T) {
-	cases := []struct {
-		in  string
-		out string
-	}{
-		{"3.5.5.RELEASE", "3.5.5"},
-		{"3.5.5-RELEASE", "3.5.5"},
-		{"2.0.0.BUILD-SNAPSHOT", "2.0.0-SNAPSHOT"},
-		{"2.0.0-BUILD-SNAPSHOT", "2.0.0-SNAPSHOT"},
-		{"2.0.0.SNAPSHOT", "2.0.0-SNAPSHOT"},
-		{"2.0.0.M7", "2.0.0-M7"},
-		{"2.0.0.RC1", "2.0.0-RC1"},
-		{"3.3.4", "3.3.4"},
-	}
-	for _, c := range cases {
-		if got := normalizeBootVersion(c.in); got != c.out {
-			t.Errorf("normalizeBootVersion(%q) = %q; want %q", c.in, got, c.out)
-		}
-	}
-}
-
 func TestBuildURL_NormalizesBootVersion(t *testing.T) {
 	o := options{
-		baseURL:     "https://start.spring.io",
-		target:      "zip",
-		projectType: "maven-project",
-		language:    "java",
-		bootVersion: "3.5.5.RELEASE",
-		groupID:     "com.example",
-		artifactID:  "demo",
-		name:        "demo",
-		description: "Demo project",
-		packageName: "com.example.demo",
-		packaging:   "jar",
-		javaVersion: "21",
-		baseDir:     "demo",
+		baseURL: "https://start.spring.io",
+		target:  "zip",
+		ProjectRequest: initializr.ProjectRequest{
+			Type:        "maven-project",
+			Language:    "java",
+			BootVersion: "3.5.5.RELEASE",
+			GroupID:     "com.example",
+			ArtifactID:  "demo",
+			Name:        "demo",
+			Description: "Demo project",
+			PackageName: "com.example.demo",
+			Packaging:   "jar",
+			JavaVersion: "21",
+			BaseDir:     "demo",
+		},
 	}
 	u, err := buildURL(o)
 	if err != nil {
@@ -121,3 +97,46 @@ func TestBuildURL_NormalizesBootVersion(t *testing.T) {
 		t.Fatalf("bootVersion not normalized: got %q, want %q", got, "3.5.5")
 	}
 }
+
+func TestDryRunFollowsServerAction(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.initializr.v2.3+json")
+		io.WriteString(w, `{"type":{"default":"maven-project","values":[{"id":"maven-project","action":"/generate/project.zip"}]}}`)
+	}))
+	defer srv.Close()
+	o := options{baseURL: srv.URL, target: "zip", dryRun: true, timeout: 5}
+	o.Type, o.ArtifactID = "maven-project", "demo"
+
+	out := captureStdout(t, func() {
+		if err := run(t.Context(), o); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if !strings.HasPrefix(out, srv.URL+"/generate/project.zip?") {
+		t.Fatalf("dry-run output = %q", out)
+	}
+}
+
+func TestResolveProjectBoundsMetadataLookup(t *testing.T) {
+	old := metadataTimeout
+	metadataTimeout = 100 * time.Millisecond
+	t.Cleanup(func() { metadataTimeout = old })
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done() // the metadata endpoints never answer
+	}))
+	defer srv.Close()
+	o := options{baseURL: srv.URL, target: "zip", timeout: 30}
+	o.Type, o.ArtifactID = "maven-project", "demo"
+
+	start := time.Now()
+	p, err := resolveProject(context.Background(), o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Fatalf("metadata lookup took %s", d)
+	}
+	if p.meta != nil || !strings.HasPrefix(p.url, srv.URL+"/starter.zip?") {
+		t.Fatalf("project = %+v", p)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/mikoto2000/spring-initializr-cli/initializr"
+)
+
+// javaReserved lists Java keywords and literals that cannot be used as
+// identifiers (and therefore not as package segments).
+var javaReserved = map[string]bool{
+	"abstract": true, "assert": true, "boolean": true, "break": true, "byte": true,
+	"case": true, "catch": true, "char": true, "class": true, "const": true,
+	"continue": true, "default": true, "do": true, "double": true, "else": true,
+	"enum": true, "extends": true, "final": true, "finally": true, "float": true,
+	"for": true, "goto": true, "if": true, "implements": true, "import": true,
+	"instanceof": true, "int": true, "interface": true, "long": true, "native": true,
+	"new": true, "package": true, "private": true, "protected": true, "public": true,
+	"return": true, "short": true, "static": true, "strictfp": true, "super": true,
+	"switch": true, "synchronized": true, "this": true, "throw": true, "throws": true,
+	"transient": true, "try": true, "void": true, "volatile": true, "while": true,
+	"true": true, "false": true, "null": true, "_": true,
+}
+
+// validateJavaPackage checks that s is a dot-separated list of Java
+// identifiers, none of which is a reserved word. It is used for both the
+// Group ID and the package name.
+func validateJavaPackage(s string) error {
+	if s == "" {
+		return errors.New("required")
+	}
+	for _, seg := range strings.Split(s, ".") {
+		if seg == "" {
+			return errors.New("empty segment")
+		}
+		if javaReserved[seg] {
+			return fmt.Errorf("'%s' is a reserved word", seg)
+		}
+		for i, r := range seg {
+			ok := unicode.IsLetter(r) || r == '_' || r == '$'
+			if i > 0 {
+				ok = ok || unicode.IsDigit(r)
+			}
+			if !ok {
+				return fmt.Errorf("invalid character %q in '%s'", r, seg)
+			}
+		}
+	}
+	return nil
+}
+
+var artifactIDPattern = regexp.MustCompile(`^[A-Za-z0-9_.\-]+$`)
+
+// validateArtifactID applies Maven's artifactId naming rule.
+func validateArtifactID(s string) error {
+	if s == "" {
+		return errors.New("required")
+	}
+	if !artifactIDPattern.MatchString(s) {
+		return errors.New("use letters, digits, '-', '_' or '.' only")
+	}
+	return nil
+}
+
+// validateJavaBoot reports whether the Java version can be used with the
+// Spring Boot version. Unknown or empty versions are accepted since the
+// server applies its own defaults.
+//   - Spring Boot 3.x and later require Java 17+
+//   - Spring Boot 2.x supports Java 8 to 21
+func validateJavaBoot(bootVersion, javaVersion string) error {
+	bootMajor, ok := leadingInt(initializr.NormalizeBootVersion(bootVersion))
+	if !ok {
+		return nil
+	}
+	java, ok := javaFeatureVersion(javaVersion)
+	if !ok {
+		return nil
+	}
+	switch {
+	case bootMajor >= 3 && java < 17:
+		return fmt.Errorf("Spring Boot %d requires Java 17+", bootMajor)
+	case bootMajor == 2 && (java < 8 || java > 21):
+		return errors.New("Spring Boot 2 supports Java 8-21")
+	}
+	return nil
+}
+
+// javaFeatureVersion parses "17" or the legacy "1.8" form into 17 and 8.
+func javaFeatureVersion(s string) (int, bool) {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(s, "1.")
+	return leadingInt(s)
+}
+
+// leadingInt parses the digits before the first '.' or '-'.
+func leadingInt(s string) (int, bool) {
+	if i := strings.IndexAny(s, ".-"); i >= 0 {
+		s = s[:i]
+	}
+	n, err := strconv.Atoi(s)
+	return n, err == nil
+}
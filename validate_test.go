@@ -0,0 +1,54 @@
+package main
+
+import "testing"
+
+func TestValidateJavaPackage(t *testing.T) {
+	valid := []string{"com.example", "com.example.demo_app", "io.$x.a1"}
+	for _, s := range valid {
+		if err := validateJavaPackage(s); err != nil {
+			t.Errorf("validateJavaPackage(%q) = %v; want nil", s, err)
+		}
+	}
+	invalid := []string{"", "com..example", "com.example.", "com.class", "com.1demo", "com.my-app", "com.example.null"}
+	for _, s := range invalid {
+		if err := validateJavaPackage(s); err == nil {
+			t.Errorf("validateJavaPackage(%q) = nil; want error", s)
+		}
+	}
+}
+
+func TestValidateArtifactID(t *testing.T) {
+	for _, s := range []string{"demo", "demo-app", "demo_app.core", "Demo2"} {
+		if err := validateArtifactID(s); err != nil {
+			t.Errorf("validateArtifactID(%q) = %v; want nil", s, err)
+		}
+	}
+	for _, s := range []string{"", "demo app", "demo/app", "démo"} {
+		if err := validateArtifactID(s); err == nil {
+			t.Errorf("validateArtifactID(%q) = nil; want error", s)
+		}
+	}
+}
+
+func TestValidateJavaBoot(t *testing.T) {
+	cases := []struct {
+		boot, java string
+		ok         bool
+	}{
+		{"3.5.5", "17", true},
+		{"3.5.5", "21", true},
+		{"3.5.5", "11", false},
+		{"3.5.5.RELEASE", "1.8", false},
+		{"4.0.0-M1", "17", true},
+		{"2.7.18", "1.8", true},
+		{"2.7.18", "24", false},
+		{"", "8", true},
+		{"3.5.5", "", true},
+	}
+	for _, c := range cases {
+		err := validateJavaBoot(c.boot, c.java)
+		if (err == nil) != c.ok {
+			t.Errorf("validateJavaBoot(%q, %q) = %v; want ok=%v", c.boot, c.java, err, c.ok)
+		}
+	}
+}